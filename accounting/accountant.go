@@ -0,0 +1,171 @@
+package accounting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+)
+
+// aggregated usage and cost
+type Totals struct {
+	Requests          int     `json:"requests"`
+	InputTokens       int     `json:"input_tokens"`
+	CachedInputTokens int     `json:"cached_input_tokens"`
+	OutputTokens      int     `json:"output_tokens"`
+	AudioSeconds      float64 `json:"audio_seconds"`
+	Images            int     `json:"images"`
+	Unmetered         int     `json:"unmetered_requests,omitempty"` // requests whose usage could not be measured, not included in Cost
+	Cost              float64 `json:"cost_usd"`
+}
+
+func (t *Totals) add(record *api.UsageRecord, cost float64) {
+	t.Requests++
+	if u := record.Usage; u != nil {
+		t.InputTokens += u.PromptTokens
+		t.OutputTokens += u.CompletionTokens
+		if u.PromptTokensDetails != nil {
+			t.CachedInputTokens += u.PromptTokensDetails.CachedTokens
+		}
+	}
+	t.AudioSeconds += record.AudioSeconds
+	t.Images += record.Images
+	if record.Unmetered {
+		t.Unmetered++
+	}
+	t.Cost += cost
+}
+
+// point-in-time copy of the aggregates
+type Snapshot struct {
+	Since          time.Time         `json:"since"`
+	Time           time.Time         `json:"time"`
+	Total          Totals            `json:"total"`
+	ByModel        map[string]Totals `json:"by_model"`
+	ByUser         map[string]Totals `json:"by_user"` // calls without the user field are under ""
+	ByTag          map[string]Totals `json:"by_tag"`  // calls without a tag are under ""
+	UnpricedModels []string          `json:"unpriced_models,omitempty"`
+}
+
+// aggregates the usage and the cost of the api calls by model, user and tag.
+// Register it with api.WithUsageRecorder. It is safe for concurrent use.
+type Accountant struct {
+	Prices PriceTable // default: DefaultPrices
+
+	mu       sync.Mutex
+	since    time.Time
+	total    Totals
+	byModel  map[string]*Totals
+	byUser   map[string]*Totals
+	byTag    map[string]*Totals
+	unpriced map[string]bool
+}
+
+func NewAccountant(prices PriceTable) *Accountant {
+	ret := &Accountant{
+		Prices: prices,
+	}
+	ret.Reset()
+	return ret
+}
+
+func (a *Accountant) prices() PriceTable {
+	if a.Prices == nil {
+		return DefaultPrices
+	}
+	return a.Prices
+}
+
+// cost of a usage record in USD, false if the model is not in the price table
+func (a *Accountant) Cost(record *api.UsageRecord) (float64, bool) {
+	p, ok := a.prices().Lookup(record.Model)
+	if !ok {
+		return 0, false
+	}
+	return p.Cost(record), true
+}
+
+func (a *Accountant) RecordUsage(ctx context.Context, record *api.UsageRecord) {
+	cost, priced := a.Cost(record)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.byModel == nil {
+		a.reset()
+	}
+	if !priced {
+		a.unpriced[record.Model] = true
+	}
+	a.total.add(record, cost)
+	for _, v := range []struct {
+		m   map[string]*Totals
+		key string
+	}{
+		{a.byModel, record.Model},
+		{a.byUser, record.User},
+		{a.byTag, record.Tag},
+	} {
+		t, ok := v.m[v.key]
+		if !ok {
+			t = &Totals{}
+			v.m[v.key] = t
+		}
+		t.add(record, cost)
+	}
+}
+
+func copyTotals(m map[string]*Totals) map[string]Totals {
+	ret := make(map[string]Totals, len(m))
+	for k, v := range m {
+		ret[k] = *v
+	}
+	return ret
+}
+
+func (a *Accountant) Snapshot() *Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ret := &Snapshot{
+		Since:   a.since,
+		Time:    time.Now(),
+		Total:   a.total,
+		ByModel: copyTotals(a.byModel),
+		ByUser:  copyTotals(a.byUser),
+		ByTag:   copyTotals(a.byTag),
+	}
+	for model := range a.unpriced {
+		ret.UnpricedModels = append(ret.UnpricedModels, model)
+	}
+	sort.Strings(ret.UnpricedModels)
+	return ret
+}
+
+// write a snapshot as JSON
+func (a *Accountant) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a.Snapshot())
+}
+
+// clear the aggregates
+func (a *Accountant) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reset()
+}
+
+// must be called with a.mu held
+func (a *Accountant) reset() {
+	a.since = time.Now()
+	a.total = Totals{}
+	a.byModel = map[string]*Totals{}
+	a.byUser = map[string]*Totals{}
+	a.byTag = map[string]*Totals{}
+	a.unpriced = map[string]bool{}
+}
+
+var _ api.UsageRecorder = (*Accountant)(nil)
@@ -0,0 +1,243 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"golang.org/x/xerrors"
+)
+
+var ErrBudgetExceeded = xerrors.New("budget exceeded")
+
+type BudgetWindow int
+
+const (
+	Daily BudgetWindow = iota
+	Monthly
+)
+
+// start of the window which contains t
+func (w BudgetWindow) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	if w == Monthly {
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func (w BudgetWindow) String() string {
+	if w == Monthly {
+		return "monthly"
+	}
+	return "daily"
+}
+
+type BudgetScope int
+
+const (
+	ScopeClient BudgetScope = iota // every call of the client
+	ScopeUser                      // the user field of the input
+	ScopeTag                       // the tag set by api.WithUsageTag
+)
+
+func (s BudgetScope) String() string {
+	switch s {
+	case ScopeUser:
+		return "user"
+	case ScopeTag:
+		return "tag"
+	default:
+		return "client"
+	}
+}
+
+// a spending limit in USD
+type Budget struct {
+	Name      string
+	Scope     BudgetScope
+	Key       string // the user or the tag, if empty the budget applies to each user or tag separately
+	Window    BudgetWindow
+	Limit     float64        // calls are rejected when the spent plus the estimated cost exceeds it, 0 means no hard limit
+	SoftLimit float64        // OnSoftLimit is called once per window when the spent exceeds it, 0 means no soft limit
+	Location  *time.Location // of the window boundaries. default: UTC
+}
+
+// the key of the record in the scope of the budget, false if the budget does not apply
+func (b *Budget) key(record *api.UsageRecord) (string, bool) {
+	var key string
+	switch b.Scope {
+	case ScopeUser:
+		key = record.User
+	case ScopeTag:
+		key = record.Tag
+	default:
+		return "", true
+	}
+	if b.Key != "" && b.Key != key {
+		return "", false
+	}
+	return key, true
+}
+
+func (b *Budget) windowStart(t time.Time) time.Time {
+	loc := b.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return b.Window.Start(t.In(loc))
+}
+
+// returned when a call is rejected, it wraps ErrBudgetExceeded
+type BudgetExceededError struct {
+	Budget   *Budget
+	Key      string // the user or the tag
+	Spent    float64
+	Estimate float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf(
+		"budget: %s, scope: %s, key: %s, window: %s, limit: %.4f, spent: %.4f, estimate: %.4f, error: %s",
+		e.Budget.Name, e.Budget.Scope, e.Key, e.Budget.Window, e.Budget.Limit, e.Spent, e.Estimate, ErrBudgetExceeded,
+	)
+}
+
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// passed to OnSoftLimit
+type BudgetEvent struct {
+	Budget *Budget
+	Key    string
+	Spent  float64
+	Record *api.UsageRecord // the call which exceeded the soft limit
+}
+
+type budgetSpendKey struct {
+	budget *Budget
+	key    string
+	start  time.Time
+}
+
+// rejects calls over budget and calls OnSoftLimit for alerting.
+// Register it with both api.WithUsageGuard, to check the estimated cost before each call,
+// and api.WithUsageRecorder, to add the actual cost after each call.
+// The estimated cost counts max_tokens, or the allowance set by api.WithOutputTokenAllowance when it is not set, as the output tokens.
+// Streams without stream options are sent with stream_options.include_usage so that their cost is recorded.
+// Concurrent calls are checked against the cost recorded so far, so they can exceed the limit slightly.
+// It is safe for concurrent use.
+type BudgetEnforcer struct {
+	Budgets     []*Budget
+	Prices      PriceTable // default: DefaultPrices
+	OnSoftLimit func(ctx context.Context, event *BudgetEvent)
+
+	mu        sync.Mutex
+	spent     map[budgetSpendKey]float64
+	softFired map[budgetSpendKey]bool
+}
+
+func NewBudgetEnforcer(budgets ...*Budget) *BudgetEnforcer {
+	return &BudgetEnforcer{
+		Budgets: budgets,
+	}
+}
+
+func (e *BudgetEnforcer) cost(record *api.UsageRecord) float64 {
+	prices := e.Prices
+	if prices == nil {
+		prices = DefaultPrices
+	}
+	p, ok := prices.Lookup(record.Model)
+	if !ok {
+		return 0
+	}
+	return p.Cost(record)
+}
+
+func (e *BudgetEnforcer) CheckUsage(ctx context.Context, estimate *api.UsageRecord) error {
+	cost := e.cost(estimate)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, b := range e.Budgets {
+		if b.Limit <= 0 {
+			continue
+		}
+		key, ok := b.key(estimate)
+		if !ok {
+			continue
+		}
+		spent := e.spent[budgetSpendKey{b, key, b.windowStart(estimate.Time)}]
+		// a call with no estimated cost is rejected once the limit is reached
+		if spent+cost > b.Limit || spent >= b.Limit {
+			return &BudgetExceededError{
+				Budget:   b,
+				Key:      key,
+				Spent:    spent,
+				Estimate: cost,
+			}
+		}
+	}
+	return nil
+}
+
+func (e *BudgetEnforcer) RecordUsage(ctx context.Context, record *api.UsageRecord) {
+	cost := e.cost(record)
+	events := []*BudgetEvent{}
+
+	e.mu.Lock()
+	if e.spent == nil {
+		e.spent = map[budgetSpendKey]float64{}
+		e.softFired = map[budgetSpendKey]bool{}
+	}
+	for _, b := range e.Budgets {
+		key, ok := b.key(record)
+		if !ok {
+			continue
+		}
+		k := budgetSpendKey{b, key, b.windowStart(record.Time)}
+		e.spent[k] += cost
+		if b.SoftLimit > 0 && e.spent[k] > b.SoftLimit && !e.softFired[k] {
+			e.softFired[k] = true
+			events = append(events, &BudgetEvent{
+				Budget: b,
+				Key:    key,
+				Spent:  e.spent[k],
+				Record: record,
+			})
+		}
+	}
+	e.prune(record.Time)
+	e.mu.Unlock()
+
+	if e.OnSoftLimit != nil {
+		for _, event := range events {
+			e.OnSoftLimit(ctx, event)
+		}
+	}
+}
+
+// drop the spent of the past windows, must be called with e.mu held
+func (e *BudgetEnforcer) prune(now time.Time) {
+	for k := range e.spent {
+		if k.start.Before(k.budget.windowStart(now)) {
+			delete(e.spent, k)
+			delete(e.softFired, k)
+		}
+	}
+}
+
+// spent of a budget in the current window, key is the user or the tag
+func (e *BudgetEnforcer) Spent(b *Budget, key string) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.spent[budgetSpendKey{b, key, b.windowStart(time.Now())}]
+}
+
+var (
+	_ api.UsageGuard    = (*BudgetEnforcer)(nil)
+	_ api.UsageRecorder = (*BudgetEnforcer)(nil)
+)
@@ -0,0 +1,172 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"github.com/ieee0824/gopenai-api/config"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+// a gpt-4o record of cost USD, the output tokens are priced at 10 USD per 1M tokens
+func gpt4oRecord(cost float64, user string, t time.Time) *api.UsageRecord {
+	return &api.UsageRecord{
+		Model: "gpt-4o",
+		User:  user,
+		Usage: &api.ChatCompletionsV1OutputUsage{CompletionTokens: int(cost * 100_000)},
+		Time:  t,
+	}
+}
+
+func TestBudgetEnforcerCheckUsage(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		budget   *Budget
+		spent    []*api.UsageRecord
+		estimate *api.UsageRecord
+		wantErr  bool
+	}{
+		{
+			name:     "within the limit",
+			budget:   &Budget{Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(0.5, "", now)},
+			estimate: gpt4oRecord(0.5, "", now),
+		},
+		{
+			name:     "over the limit",
+			budget:   &Budget{Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(0.5, "", now)},
+			estimate: gpt4oRecord(0.6, "", now),
+			wantErr:  true,
+		},
+		{
+			name:     "no estimated cost after the limit is reached",
+			budget:   &Budget{Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(1, "", now)},
+			estimate: &api.UsageRecord{Model: "unknown-model", Time: now},
+			wantErr:  true,
+		},
+		{
+			name:     "no hard limit",
+			budget:   &Budget{SoftLimit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(2, "", now)},
+			estimate: gpt4oRecord(1, "", now),
+		},
+		{
+			name:     "spent by another user",
+			budget:   &Budget{Scope: ScopeUser, Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(1, "alice", now)},
+			estimate: gpt4oRecord(0.5, "bob", now),
+		},
+		{
+			name:     "spent by the same user",
+			budget:   &Budget{Scope: ScopeUser, Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(1, "alice", now)},
+			estimate: gpt4oRecord(0.5, "alice", now),
+			wantErr:  true,
+		},
+		{
+			name:     "budget of another user",
+			budget:   &Budget{Scope: ScopeUser, Key: "alice", Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(1, "bob", now)},
+			estimate: gpt4oRecord(2, "bob", now),
+		},
+		{
+			name:     "spent in the previous day",
+			budget:   &Budget{Window: Daily, Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(1, "", now.Add(-24*time.Hour))},
+			estimate: gpt4oRecord(0.5, "", now),
+		},
+		{
+			name:     "spent earlier in the month",
+			budget:   &Budget{Window: Monthly, Limit: 1},
+			spent:    []*api.UsageRecord{gpt4oRecord(1, "", now.Add(-24*time.Hour))},
+			estimate: gpt4oRecord(0.5, "", now),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewBudgetEnforcer(tt.budget)
+			for _, r := range tt.spent {
+				e.RecordUsage(context.Background(), r)
+			}
+			err := e.CheckUsage(context.Background(), tt.estimate)
+			if tt.wantErr && !xerrors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("CheckUsage() error = %v, want ErrBudgetExceeded", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("CheckUsage() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestBudgetEnforcerSoftLimit(t *testing.T) {
+	now := time.Now()
+	events := []*BudgetEvent{}
+	e := NewBudgetEnforcer(&Budget{Scope: ScopeUser, SoftLimit: 1})
+	e.OnSoftLimit = func(ctx context.Context, event *BudgetEvent) {
+		events = append(events, event)
+	}
+	for _, r := range []*api.UsageRecord{
+		gpt4oRecord(0.6, "alice", now),
+		gpt4oRecord(0.6, "alice", now), // exceeds the soft limit
+		gpt4oRecord(0.6, "alice", now), // already fired in the window
+		gpt4oRecord(1.2, "bob", now),
+	} {
+		e.RecordUsage(context.Background(), r)
+	}
+	if len(events) != 2 || events[0].Key != "alice" || events[1].Key != "bob" {
+		t.Fatalf("events = %+v, want alice and bob once", events)
+	}
+	if spent := e.Spent(e.Budgets[0], "alice"); fmt.Sprintf("%.2f", spent) != "1.80" {
+		t.Errorf("Spent(alice) = %v, want 1.8", spent)
+	}
+}
+
+// the estimate counts the output token allowance, or the cap of the model on opt-in, when max_tokens is not set
+func TestBudgetEnforcerOutputTokenAllowance(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "hi"}}], "usage": {"prompt_tokens": 10, "completion_tokens": 1, "total_tokens": 11}}`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		opts      []api.Option
+		maxTokens *int
+		wantErr   bool
+	}{
+		{name: "default allowance"},
+		{name: "allowance", opts: []api.Option{api.WithOutputTokenAllowance(2048)}},
+		{name: "model cap", opts: []api.Option{api.WithOutputTokenAllowance(api.MaxOutputTokensAllowance)}, wantErr: true},
+		{name: "max_tokens", opts: []api.Option{api.WithOutputTokenAllowance(api.MaxOutputTokensAllowance)}, maxTokens: lo.ToPtr(1024)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 0.05 USD is about 5000 output tokens of gpt-5, fewer than its cap of 128000
+			e := NewBudgetEnforcer(&Budget{Limit: 0.05})
+			opts := append([]api.Option{api.WithUsageGuard(e), api.WithUsageRecorder(e)}, tt.opts...)
+			ai := api.New(&config.Configuration{ApiKey: lo.ToPtr("key"), Endpoint: lo.ToPtr(srv.URL)}, opts...)
+			_, err := ai.ChatCompletionsV1(&api.ChatCompletionsV1Input{
+				Model:     lo.ToPtr("gpt-5"),
+				Messages:  []api.Message{{Role: "user", Content: "hello"}},
+				MaxTokens: tt.maxTokens,
+			})
+			if tt.wantErr && !xerrors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("error = %v, want ErrBudgetExceeded", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package accounting prices the usage of the api calls and aggregates the cost.
+package accounting
+
+import "github.com/ieee0824/gopenai-api/api"
+
+// prices in USD. Token prices are per 1M tokens.
+type Price struct {
+	Input       float64            `json:"input,omitempty"`
+	CachedInput float64            `json:"cached_input,omitempty"` // default: Input
+	Output      float64            `json:"output,omitempty"`
+	AudioInput  float64            `json:"audio_input,omitempty"`  // default: Input
+	AudioOutput float64            `json:"audio_output,omitempty"` // default: Output
+	PerMinute   float64            `json:"per_minute,omitempty"`   // transcriptions
+	PerImage    map[string]float64 `json:"per_image,omitempty"`    // by quality and size such as hd/1024x1024, or by size. "" is used for unknown sizes
+}
+
+// prices by model, looked up with api.LookupModel. Fine-tuned models are priced by the ft: entry of the base model.
+type PriceTable map[string]Price
+
+// list prices of the standard tier, check https://openai.com/api/pricing and override them as needed
+var DefaultPrices = PriceTable{
+	"gpt-5":                  {Input: 1.25, CachedInput: 0.125, Output: 10},
+	"gpt-5-mini":             {Input: 0.25, CachedInput: 0.025, Output: 2},
+	"gpt-5-nano":             {Input: 0.05, CachedInput: 0.005, Output: 0.4},
+	"gpt-4.1":                {Input: 2, CachedInput: 0.5, Output: 8},
+	"gpt-4.1-mini":           {Input: 0.4, CachedInput: 0.1, Output: 1.6},
+	"gpt-4.1-nano":           {Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	"gpt-4o":                 {Input: 2.5, CachedInput: 1.25, Output: 10},
+	"gpt-4o-2024-05-13":      {Input: 5, Output: 15},
+	"gpt-4o-mini":            {Input: 0.15, CachedInput: 0.075, Output: 0.6},
+	"gpt-4o-audio-preview":   {Input: 2.5, Output: 10, AudioInput: 40, AudioOutput: 80},
+	"chatgpt-4o-latest":      {Input: 5, Output: 15},
+	"gpt-4-turbo":            {Input: 10, Output: 30},
+	"gpt-4-turbo-preview":    {Input: 10, Output: 30},
+	"gpt-4":                  {Input: 30, Output: 60},
+	"gpt-4-32k":              {Input: 60, Output: 120},
+	"gpt-3.5-turbo":          {Input: 0.5, Output: 1.5},
+	"gpt-3.5-turbo-instruct": {Input: 1.5, Output: 2},
+	"o1":                     {Input: 15, CachedInput: 7.5, Output: 60},
+	"o1-preview":             {Input: 15, CachedInput: 7.5, Output: 60},
+	"o1-mini":                {Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	"o1-pro":                 {Input: 150, Output: 600},
+	"o3":                     {Input: 2, CachedInput: 0.5, Output: 8},
+	"o3-pro":                 {Input: 20, Output: 80},
+	"o3-mini":                {Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	"o4-mini":                {Input: 1.1, CachedInput: 0.275, Output: 4.4},
+	"ft:gpt-4.1":             {Input: 3, CachedInput: 0.75, Output: 12},
+	"ft:gpt-4.1-mini":        {Input: 0.8, CachedInput: 0.2, Output: 3.2},
+	"ft:gpt-4.1-nano":        {Input: 0.2, CachedInput: 0.05, Output: 0.8},
+	"ft:gpt-4o":              {Input: 3.75, CachedInput: 1.875, Output: 15},
+	"ft:gpt-4o-mini":         {Input: 0.3, CachedInput: 0.15, Output: 1.2},
+	"ft:gpt-3.5-turbo":       {Input: 3, Output: 6},
+	"whisper-1":              {PerMinute: 0.006},
+	"dall-e-2":               {PerImage: map[string]float64{"": 0.02, "1024x1024": 0.02, "512x512": 0.018, "256x256": 0.016}},
+	"dall-e-3":               {PerImage: map[string]float64{"": 0.04, "1024x1024": 0.04, "1024x1792": 0.08, "1792x1024": 0.08, "hd/1024x1024": 0.08, "hd/1024x1792": 0.12, "hd/1792x1024": 0.12}},
+}
+
+// price of a model. Fine-tuned models such as ft:gpt-4o-mini-2024-07-18:org::id are looked up as ft:gpt-4o-mini-2024-07-18.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if base, ok := api.FineTunedBaseModel(model); ok {
+		model = "ft:" + base
+	}
+	return api.LookupModel(t, model)
+}
+
+// a copy of the table with prices added or replaced
+func (t PriceTable) With(overrides PriceTable) PriceTable {
+	ret := PriceTable{}
+	for k, v := range t {
+		ret[k] = v
+	}
+	for k, v := range overrides {
+		ret[k] = v
+	}
+	return ret
+}
+
+// cost of a usage record in USD
+func (p Price) Cost(record *api.UsageRecord) float64 {
+	ret := 0.0
+	if u := record.Usage; u != nil {
+		cachedInput, audioInput, audioOutput := 0, 0, 0
+		if u.PromptTokensDetails != nil {
+			cachedInput = u.PromptTokensDetails.CachedTokens
+			audioInput = u.PromptTokensDetails.AudioTokens
+		}
+		if u.CompletionTokensDetails != nil {
+			audioOutput = u.CompletionTokensDetails.AudioTokens
+		}
+		ret += float64(u.PromptTokens-cachedInput-audioInput) * p.Input
+		ret += float64(cachedInput) * or(p.CachedInput, p.Input)
+		ret += float64(audioInput) * or(p.AudioInput, p.Input)
+		ret += float64(u.CompletionTokens-audioOutput) * p.Output
+		ret += float64(audioOutput) * or(p.AudioOutput, p.Output)
+		ret /= 1_000_000
+	}
+	ret += record.AudioSeconds / 60 * p.PerMinute
+	if record.Images > 0 {
+		perImage, ok := p.PerImage[record.ImageQuality+"/"+record.ImageSize]
+		if !ok {
+			perImage, ok = p.PerImage[record.ImageSize]
+		}
+		if !ok {
+			perImage = p.PerImage[""]
+		}
+		ret += float64(record.Images) * perImage
+	}
+	return ret
+}
+
+func or(v, fallback float64) float64 {
+	if v == 0 {
+		return fallback
+	}
+	return v
+}
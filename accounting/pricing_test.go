@@ -0,0 +1,133 @@
+package accounting
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ieee0824/gopenai-api/api"
+)
+
+func TestPriceTableLookup(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string // the entry of DefaultPrices, empty if not found
+	}{
+		{"gpt-4o", "gpt-4o"},
+		{"gpt-4o-2024-08-06", "gpt-4o"},
+		{"gpt-4o-2024-05-13", "gpt-4o-2024-05-13"},
+		{"gpt-4o-mini-2024-07-18", "gpt-4o-mini"},
+		{"gpt-4.1-nano-2025-04-14", "gpt-4.1-nano"},
+		{"o3-mini-2025-01-31", "o3-mini"},
+		{"ft:gpt-4o-mini-2024-07-18:org::id", "ft:gpt-4o-mini"},
+		{"ft:gpt-3.5-turbo:org:suffix:id", "ft:gpt-3.5-turbo"},
+		{"gpt-4o-realtime-preview", ""},
+		{"o1-2024-12-17-custom", ""},
+		{"ft:davinci-002:org::id", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := DefaultPrices.Lookup(tt.model)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("Lookup(%q) = %+v, want not found", tt.model, got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Lookup(%q) not found, want %s", tt.model, tt.want)
+			}
+			if want := DefaultPrices[tt.want]; got.Input != want.Input || got.Output != want.Output {
+				t.Errorf("Lookup(%q) = %+v, want %+v of %s", tt.model, got, want, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  string
+		record *api.UsageRecord
+		want   float64
+	}{
+		{
+			name:  "tokens",
+			model: "gpt-4o",
+			record: &api.UsageRecord{Usage: &api.ChatCompletionsV1OutputUsage{
+				PromptTokens:     1_000_000,
+				CompletionTokens: 1_000_000,
+			}},
+			want: 12.5,
+		},
+		{
+			name:  "cached tokens",
+			model: "gpt-4o",
+			record: &api.UsageRecord{Usage: &api.ChatCompletionsV1OutputUsage{
+				PromptTokens:        1_000_000,
+				PromptTokensDetails: &api.ChatCompletionsV1OutputUsagePromptTokensDetails{CachedTokens: 500_000},
+			}},
+			want: 1.875,
+		},
+		{
+			name:  "cached tokens without a cached price",
+			model: "gpt-4-turbo",
+			record: &api.UsageRecord{Usage: &api.ChatCompletionsV1OutputUsage{
+				PromptTokens:        1_000_000,
+				PromptTokensDetails: &api.ChatCompletionsV1OutputUsagePromptTokensDetails{CachedTokens: 500_000},
+			}},
+			want: 10,
+		},
+		{
+			name:  "audio tokens",
+			model: "gpt-4o-audio-preview",
+			record: &api.UsageRecord{Usage: &api.ChatCompletionsV1OutputUsage{
+				PromptTokens:            1_000_000,
+				CompletionTokens:        1_000_000,
+				PromptTokensDetails:     &api.ChatCompletionsV1OutputUsagePromptTokensDetails{AudioTokens: 1_000_000},
+				CompletionTokensDetails: &api.ChatCompletionsV1OutputUsageCompletionTokensDetails{AudioTokens: 1_000_000},
+			}},
+			want: 120,
+		},
+		{
+			name:   "transcription",
+			model:  "whisper-1",
+			record: &api.UsageRecord{AudioSeconds: 90},
+			want:   0.009,
+		},
+		{
+			name:   "dall-e-2",
+			model:  "dall-e-2",
+			record: &api.UsageRecord{Images: 2, ImageSize: "512x512"},
+			want:   0.036,
+		},
+		{
+			name:   "dall-e-3 standard",
+			model:  "dall-e-3",
+			record: &api.UsageRecord{Images: 1, ImageSize: "1024x1792"},
+			want:   0.08,
+		},
+		{
+			name:   "dall-e-3 hd",
+			model:  "dall-e-3",
+			record: &api.UsageRecord{Images: 1, ImageSize: "1792x1024", ImageQuality: "hd"},
+			want:   0.12,
+		},
+		{
+			name:   "unknown size",
+			model:  "dall-e-3",
+			record: &api.UsageRecord{Images: 1, ImageSize: "2048x2048", ImageQuality: "hd"},
+			want:   0.04,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := DefaultPrices.Lookup(tt.model)
+			if !ok {
+				t.Fatalf("Lookup(%q) not found", tt.model)
+			}
+			if got := p.Cost(tt.record); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
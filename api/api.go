@@ -2,26 +2,80 @@ package api
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/ieee0824/gopenai-api/config"
 	"golang.org/x/xerrors"
 )
 
 type OpenAIAPI struct {
-	httpClient    *http.Client // default: http.DefaultClient
-	configuration *config.Configuration
+	httpClient     *http.Client // default: http.DefaultClient
+	configuration  *config.Configuration
+	pool           *Pool
+	limiter        *Limiter
+	usageRecorders []UsageRecorder
+	usageGuards    []UsageGuard
+	interceptors   []*Interceptor
+
+	outputTokenAllowance int
+
+	logger          *slog.Logger
+	logRedactFields map[string]bool
 }
 
-func New(cfg *config.Configuration) OpenAIAPIIface {
+type Option func(*OpenAIAPI)
+
+func WithHTTPClient(c *http.Client) Option {
+	return func(api *OpenAIAPI) {
+		api.httpClient = c
+	}
+}
 
-	return &OpenAIAPI{
-		httpClient:    http.DefaultClient,
-		configuration: cfg,
+// spread requests across the members of the pool.
+// The credentials and the endpoint of the selected member override the configuration.
+// The Azure mode is not supported, the requests fail with ErrPoolAzureUnsupported.
+func WithPool(p *Pool) Option {
+	return func(api *OpenAIAPI) {
+		api.pool = p
 	}
 }
 
+// enforce the requests and tokens per minute budgets on the client side
+func WithLimiter(l *Limiter) Option {
+	return func(api *OpenAIAPI) {
+		api.limiter = l
+	}
+}
+
+func New(cfg *config.Configuration, opts ...Option) OpenAIAPIIface {
+	ret := &OpenAIAPI{
+		httpClient:           http.DefaultClient,
+		configuration:        cfg,
+		outputTokenAllowance: DefaultOutputTokenAllowance,
+	}
+	for _, opt := range opts {
+		opt(ret)
+	}
+	if ret.logger != nil {
+		ret.interceptors = append(ret.interceptors, ret.loggingInterceptor())
+	}
+	return ret
+}
+
+// every request is sent through this method, it also collects the metadata of the response
+func (api *OpenAIAPI) do(req *http.Request) (*http.Response, *ResponseMetadata, error) {
+	start := time.Now()
+	resp, err := api.roundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, newResponseMetadata(resp, time.Since(start)), nil
+}
+
 func (api *OpenAIAPI) endpoint() (*url.URL, error) {
 	if api.configuration.Endpoint == nil {
 		return url.Parse("https://api.openai.com")
@@ -29,14 +83,83 @@ func (api *OpenAIAPI) endpoint() (*url.URL, error) {
 	return url.Parse(*api.configuration.Endpoint)
 }
 
+// build the request url of path such as /v1/chat/completions, path must be escaped.
+// The path is joined to the path of the endpoint, for example http://host/proxy sends to http://host/proxy/v1/...
+// In the Azure mode, requests with a model are sent to /openai/deployments/{deployment}/...
+// and the others to /openai/..., with the api-version query parameter.
+func (api *OpenAIAPI) requestURL(path string, model *string) (*url.URL, error) {
+	endpoint, err := api.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	azure := api.configuration.Azure
+	if azure == nil {
+		return endpoint, joinEscapedPath(endpoint, path)
+	}
+
+	rest := strings.TrimPrefix(path, "/v1")
+	if model != nil {
+		err = joinEscapedPath(endpoint, "/openai/deployments/"+url.PathEscape(azure.Deployment(*model))+rest)
+	} else {
+		err = joinEscapedPath(endpoint, "/openai"+rest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	q := endpoint.Query()
+	q.Set("api-version", azure.Version())
+	endpoint.RawQuery = q.Encode()
+	return endpoint, nil
+}
+
+// join the path to the path of u keeping its escaping, so that %2F in an id is not sent as /
+func joinEscapedPath(u *url.URL, escaped string) error {
+	escaped = strings.TrimSuffix(u.EscapedPath(), "/") + escaped
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return err
+	}
+	u.Path = path
+	u.RawPath = escaped
+	return nil
+}
+
 func (api *OpenAIAPI) setToken(req *http.Request) error {
-	if api.configuration.ApiKey == nil {
+	azure := api.configuration.Azure
+	if api.pool != nil {
+		if azure != nil {
+			return ErrPoolAzureUnsupported
+		}
+		// set by the selected pool member
+		return nil
+	}
+	if azure != nil && azure.TokenSource != nil {
+		token, err := azure.TokenSource.Token(req.Context())
+		if err != nil {
+			return xerrors.Errorf("failed to get azure token: %w", err)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		return nil
+	}
+
+	credentials, err := api.configuration.ResolveCredentials(req.Context())
+	if err != nil {
+		return xerrors.Errorf("failed to resolve credentials: %w", err)
+	}
+	if credentials == nil || credentials.ApiKey == "" {
 		return xerrors.New("no token")
 	}
-	if api.configuration.Organization == nil {
-		return xerrors.New("no organization")
+	if azure != nil {
+		req.Header.Set("api-key", credentials.ApiKey)
+		return nil
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", credentials.ApiKey))
+	if credentials.Organization != "" {
+		req.Header.Set("OpenAI-Organization", credentials.Organization)
+	}
+	if credentials.Project != "" {
+		req.Header.Set("OpenAI-Project", credentials.Project)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", *api.configuration.ApiKey))
-	req.Header.Add("OpenAI-Organization", *api.configuration.Organization)
 	return nil
 }
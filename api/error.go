@@ -8,6 +8,15 @@ var ErrUnauthorized = xerrors.New("Unauthorized")
 var ErrUnknown = xerrors.New("Unkonown")
 var ErrStatusBadGateway = xerrors.New("Bad Gateway")
 var ErrParseFunctionCallingArguments = xerrors.New("failed to parse function calling arguments")
+var ErrToolNotFound = xerrors.New("tool not found")
+var ErrUnsupportedSchema = xerrors.New("unsupported schema")
+var ErrNoAvailablePoolMember = xerrors.New("no available pool member")
+var ErrNoCredentialsProvider = xerrors.New("no credentials provider")
+var ErrPoolAzureUnsupported = xerrors.New("the pool does not support the azure mode")
+var ErrLimiterBudgetExceeded = xerrors.New("client side rate limit budget exceeded")
+var ErrToolPanicked = xerrors.New("tool handler panicked")
+var ErrToolMaxIterations = xerrors.New("tool calling exceeded max iterations")
+var ErrInterceptorType = xerrors.New("interceptor changed the type of the input or the output")
 
 type Error struct {
 	Message string `json:"message"`
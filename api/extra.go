@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// encode v and merge extraBody into the top-level json object.
+// Values in extraBody override the typed fields.
+func marshalWithExtraBody(v any, extraBody map[string]any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(extraBody) == 0 {
+		return b, nil
+	}
+	merged := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range extraBody {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		merged[k] = raw
+	}
+	return json.Marshal(merged)
+}
+
+func withStream(extraBody map[string]any, stream bool) map[string]any {
+	if !stream {
+		return extraBody
+	}
+	ret := map[string]any{}
+	for k, v := range extraBody {
+		ret[k] = v
+	}
+	ret["stream"] = true
+	return ret
+}
+
+// write extraBody as multipart form fields.
+// Strings and numbers are written as is, other values are encoded to json.
+func writeExtraFields(w *multipart.Writer, extraBody map[string]any) error {
+	keys := make([]string, 0, len(extraBody))
+	for k := range extraBody {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var value string
+		switch v := extraBody[k].(type) {
+		case string:
+			value = v
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+			value = fmt.Sprint(v)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			value = string(b)
+		}
+		if err := w.WriteField(k, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setExtraQuery(u *url.URL, extraQuery url.Values) {
+	if len(extraQuery) == 0 {
+		return
+	}
+	q := u.Query()
+	for k, vs := range extraQuery {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+}
+
+var knownFieldsCache sync.Map // reflect.Type -> map[string]bool
+
+func knownFields(t reflect.Type) map[string]bool {
+	if v, ok := knownFieldsCache.Load(t); ok {
+		return v.(map[string]bool)
+	}
+	ret := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for k := range knownFields(f.Type) {
+				ret[k] = true
+			}
+			continue
+		}
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		ret[name] = true
+	}
+	knownFieldsCache.Store(t, ret)
+	return ret
+}
+
+// decode data into v which must be a pointer to an alias of a struct type,
+// and return the top-level fields which are not declared in the struct
+func unmarshalWithExtraFields(data []byte, v any) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	all := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		// not an object
+		return nil, nil
+	}
+	known := knownFields(reflect.TypeOf(v).Elem())
+	ret := map[string]json.RawMessage{}
+	for k, raw := range all {
+		if !known[k] {
+			ret[k] = raw
+		}
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return ret, nil
+}
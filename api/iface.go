@@ -1,9 +1,34 @@
 package api
 
+import "context"
+
 type OpenAIAPIIface interface {
 	ListModelsV1(*ListModelsV1Input) (*ListModelsV1Output, error)
+	RetrieveModelV1(input *RetrieveModelV1Input) (*RetrieveModelV1Output, error)
+	DeleteModelV1(input *DeleteModelV1Input) (*DeleteModelV1Output, error)
 	ChatCompletionsV1(input *ChatCompletionsV1Input) (*ChatCompletionsV1Output, error)
+	ChatCompletionsV1WithContext(ctx context.Context, input *ChatCompletionsV1Input) (*ChatCompletionsV1Output, error)
+	ChatCompletionsV1Stream(ctx context.Context, input *ChatCompletionsV1Input) (*Stream[ChatCompletionsV1StreamChunk], error)
+	CompletionsV1(input *CompletionsV1Input) (*CompletionsV1Output, error)
+	CompletionsV1WithContext(ctx context.Context, input *CompletionsV1Input) (*CompletionsV1Output, error)
+	CompletionsV1Stream(ctx context.Context, input *CompletionsV1Input) (*Stream[CompletionsV1Output], error)
 	AudioTranscriptionsV1(input *AudioTranscriptionsV1Input) (*AudioTranscriptionsV1Output, error)
+	AudioTranscriptionsV1WithContext(ctx context.Context, input *AudioTranscriptionsV1Input) (*AudioTranscriptionsV1Output, error)
 	ListFileV1(input *ListFileV1Input) (*ListFileV1Output, error)
 	ImagesGenerationsV1(input *ImagesGenerationsV1Input) (*ImagesGenerationsV1Output, error)
+	ImagesGenerationsV1WithContext(ctx context.Context, input *ImagesGenerationsV1Input) (*ImagesGenerationsV1Output, error)
+}
+
+// the context variants of the models and files methods, kept out of OpenAIAPIIface
+// so that its implementations and mocks do not break. The client returned by New implements it.
+// example:
+//
+//	models, err := ai.(api.OpenAIAPIContextIface).ListModelsV1WithContext(ctx, nil)
+type OpenAIAPIContextIface interface {
+	ListModelsV1WithContext(ctx context.Context, input *ListModelsV1Input) (*ListModelsV1Output, error)
+	RetrieveModelV1WithContext(ctx context.Context, input *RetrieveModelV1Input) (*RetrieveModelV1Output, error)
+	DeleteModelV1WithContext(ctx context.Context, input *DeleteModelV1Input) (*DeleteModelV1Output, error)
+	ListFileV1WithContext(ctx context.Context, input *ListFileV1Input) (*ListFileV1Output, error)
 }
+
+var _ OpenAIAPIContextIface = (*OpenAIAPI)(nil)
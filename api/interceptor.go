@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+// an api call seen by the interceptors
+type Call struct {
+	Endpoint string         // one of the Endpoint constants
+	Input    any            // the typed input such as *ChatCompletionsV1Input
+	Request  *http.Request  // set when the request is sent, nil if the call fails before it is built
+	Response *http.Response // set after the response is received. The body of streams is read by the caller
+}
+
+// the rest of the chain of a call, it returns the typed output such as *ChatCompletionsV1Output
+type CallHandler func(ctx context.Context, call *Call) (any, error)
+
+// the rest of the chain of an http round trip
+type RoundTripHandler func(req *http.Request) (*http.Response, error)
+
+// intercepts the api calls of a client. Either function can be nil.
+//
+// Call wraps the whole call with the typed input and output.
+// It can replace call.Input with a value of the same type, return an output without calling next to short-circuit,
+// or modify the output and the error returned by next.
+// next returns a stream when it is opened, Stream.Observe sees its events and its end.
+//
+// RoundTrip wraps the http round trip of the call after the request is built.
+// It can modify or replace the request, return a response without calling next,
+// or modify the response and the error returned by next.
+// With a pool, the credentials of the selected member are set after the chain.
+type Interceptor struct {
+	Call      func(ctx context.Context, call *Call, next CallHandler) (any, error)
+	RoundTrip func(call *Call, req *http.Request, next RoundTripHandler) (*http.Response, error)
+}
+
+// add interceptors, it can be used several times. The first interceptor is the outermost.
+func WithInterceptor(interceptors ...*Interceptor) Option {
+	return func(api *OpenAIAPI) {
+		api.interceptors = append(api.interceptors, interceptors...)
+	}
+}
+
+// the model of the input, empty if the input has no model
+func (c *Call) Model() string {
+	switch input := c.Input.(type) {
+	case *ChatCompletionsV1Input:
+		return lo.FromPtr(input.Model)
+	case *CompletionsV1Input:
+		return lo.FromPtr(input.Model)
+	case *ImagesGenerationsV1Input:
+		return lo.FromPtr(input.Model)
+	case *AudioTranscriptionsV1Input:
+		return lo.FromPtr(input.Model)
+	case *RetrieveModelV1Input:
+		return lo.FromPtr(input.Model)
+	case *DeleteModelV1Input:
+		return lo.FromPtr(input.Model)
+	}
+	return ""
+}
+
+// the token usage of an output of chat completions or completions, nil for the others
+func OutputUsage(output any) *ChatCompletionsV1OutputUsage {
+	switch output := output.(type) {
+	case *ChatCompletionsV1Output:
+		if output != nil {
+			return output.Usage
+		}
+	case *CompletionsV1Output:
+		if output != nil {
+			return output.Usage
+		}
+	}
+	return nil
+}
+
+// the response metadata of an output such as *ChatCompletionsV1Output or a stream, nil if there is none
+func OutputResponseMetadata(output any) *ResponseMetadata {
+	v := reflect.ValueOf(output)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if s, ok := output.(interface{ ResponseMetadata() *ResponseMetadata }); ok {
+		return s.ResponseMetadata()
+	}
+	f := v.Elem().FieldByName("ResponseMetadata")
+	if !f.IsValid() {
+		return nil
+	}
+	md, _ := f.Interface().(*ResponseMetadata)
+	return md
+}
+
+type callKey struct{}
+
+// the call in progress, available in the context passed to the interceptors and to the http request
+func CallFromContext(ctx context.Context) (*Call, bool) {
+	call, ok := ctx.Value(callKey{}).(*Call)
+	return call, ok
+}
+
+// run f through the Call chain of the interceptors
+func intercept[I, O any](api *OpenAIAPI, ctx context.Context, endpoint string, input *I, f func(ctx context.Context, input *I) (*O, error)) (*O, error) {
+	if len(api.interceptors) == 0 {
+		return f(ctx, input)
+	}
+	call := &Call{
+		Endpoint: endpoint,
+		Input:    input,
+	}
+	ctx = context.WithValue(ctx, callKey{}, call)
+
+	var next CallHandler = func(ctx context.Context, call *Call) (any, error) {
+		input, ok := call.Input.(*I)
+		if !ok {
+			return nil, xerrors.Errorf("endpoint: %s, input: %T, error: %w", call.Endpoint, call.Input, ErrInterceptorType)
+		}
+		ret, err := f(ctx, input)
+		if ret == nil {
+			// avoid a non-nil interface holding a nil pointer
+			return nil, err
+		}
+		return ret, err
+	}
+	for i := len(api.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := api.interceptors[i], next
+		if interceptor.Call == nil {
+			continue
+		}
+		next = func(ctx context.Context, call *Call) (any, error) {
+			return interceptor.Call(ctx, call, inner)
+		}
+	}
+
+	out, err := next(ctx, call)
+	if out == nil {
+		return nil, err
+	}
+	ret, ok := out.(*O)
+	if !ok {
+		return nil, xerrors.Errorf("endpoint: %s, output: %T, error: %w", endpoint, out, ErrInterceptorType)
+	}
+	return ret, err
+}
+
+// send req through the RoundTrip chain of the interceptors
+func (api *OpenAIAPI) roundTrip(req *http.Request) (*http.Response, error) {
+	call, ok := CallFromContext(req.Context())
+	var next RoundTripHandler = func(req *http.Request) (*http.Response, error) {
+		if ok {
+			call.Request = req
+		}
+		if api.pool != nil {
+			return api.pool.do(api.httpClient, req)
+		}
+		return api.httpClient.Do(req)
+	}
+	if !ok {
+		return next(req)
+	}
+	for i := len(api.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := api.interceptors[i], next
+		if interceptor.RoundTrip == nil {
+			continue
+		}
+		next = func(req *http.Request) (*http.Response, error) {
+			return interceptor.RoundTrip(call, req, inner)
+		}
+	}
+	call.Request = req
+	resp, err := next(req)
+	call.Response = resp
+	return resp, err
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type JSONRepair string
+
+const (
+	JSONRepairStripCodeFence       JSONRepair = "strip_code_fence"
+	JSONRepairRemoveTrailingComma  JSONRepair = "remove_trailing_comma"
+	JSONRepairEscapeQuote          JSONRepair = "escape_quote"
+	JSONRepairEscapeControlChar    JSONRepair = "escape_control_character"
+	JSONRepairCloseString          JSONRepair = "close_string"
+	JSONRepairCompleteValue        JSONRepair = "complete_value"
+	JSONRepairDropIncompleteMember JSONRepair = "drop_incomplete_member"
+	JSONRepairCloseObject          JSONRepair = "close_object"
+	JSONRepairCloseArray           JSONRepair = "close_array"
+	JSONRepairDropUnmatchedBracket JSONRepair = "drop_unmatched_bracket"
+)
+
+type jsonRepairer struct {
+	repairs []JSONRepair
+}
+
+func (r *jsonRepairer) add(repair JSONRepair) {
+	for _, v := range r.repairs {
+		if v == repair {
+			return
+		}
+	}
+	r.repairs = append(r.repairs, repair)
+}
+
+type jsonCutPoint struct {
+	length int
+	stack  string
+}
+
+// repair common defects of json generated by the model.
+// The following defects are repaired:
+//   - markdown code fences around the json
+//   - trailing commas in objects and arrays
+//   - unescaped quotes and control characters in strings
+//   - unterminated strings, objects and arrays (for example finish_reason is "length")
+//   - unmatched closing brackets
+//
+// It returns the repaired json and the list of applied repairs.
+// If data is already valid, it is returned as is with no repairs.
+// Only the last member of truncated data can be dropped, when it is incomplete.
+// Otherwise it returns an error rather than dropping complete members.
+func RepairJSON(data string) (string, []JSONRepair, error) {
+	if json.Valid([]byte(data)) {
+		return data, nil, nil
+	}
+	r := &jsonRepairer{}
+	s := r.stripCodeFence(strings.TrimSpace(data))
+	if json.Valid([]byte(s)) {
+		return s, r.repairs, nil
+	}
+
+	out := new(strings.Builder)
+	stack := []byte{}
+	cutPoints := []jsonCutPoint{}
+	inString, inKey := false, false
+	last := byte(0) // the last byte written outside of strings, except whitespace
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case c == '\\' && i+1 < len(s):
+				out.WriteByte(c)
+				out.WriteByte(s[i+1])
+				i++
+			case c == '\\':
+				// dangling escape at the end of truncated data
+			case c == '"':
+				// keys do not have quotes, so the first quote closes a key
+				if inKey || closesString(s[i+1:], len(stack) > 0 && stack[len(stack)-1] == '{') {
+					inString = false
+					last = c
+					out.WriteByte(c)
+				} else {
+					r.add(JSONRepairEscapeQuote)
+					out.WriteString(`\"`)
+				}
+			case c == '\n':
+				r.add(JSONRepairEscapeControlChar)
+				out.WriteString(`\n`)
+			case c == '\r':
+				r.add(JSONRepairEscapeControlChar)
+				out.WriteString(`\r`)
+			case c == '\t':
+				r.add(JSONRepairEscapeControlChar)
+				out.WriteString(`\t`)
+			default:
+				out.WriteByte(c)
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			inKey = len(stack) > 0 && stack[len(stack)-1] == '{' && (last == '{' || last == ',')
+			out.WriteByte(c)
+		case '{', '[':
+			out.WriteByte(c)
+			last = c
+			stack = append(stack, c)
+			cutPoints = append(cutPoints, jsonCutPoint{length: out.Len(), stack: string(stack)})
+		case '}', ']':
+			open := strings.LastIndexByte(string(stack), openingBracket(c))
+			if open < 0 {
+				r.add(JSONRepairDropUnmatchedBracket)
+				continue
+			}
+			// close the brackets opened after the matching one, for example ] in {"a": [1}
+			for len(stack)-1 > open {
+				if stack[len(stack)-1] == '{' {
+					r.add(JSONRepairCloseObject)
+					out.WriteByte('}')
+				} else {
+					r.add(JSONRepairCloseArray)
+					out.WriteByte(']')
+				}
+				stack = stack[:len(stack)-1]
+			}
+			stack = stack[:len(stack)-1]
+			out.WriteByte(c)
+			last = c
+		case ',':
+			next := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if next == "" || next[0] == '}' || next[0] == ']' {
+				r.add(JSONRepairRemoveTrailingComma)
+				continue
+			}
+			cutPoints = append(cutPoints, jsonCutPoint{length: out.Len(), stack: string(stack)})
+			out.WriteByte(c)
+			last = c
+		default:
+			out.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				last = c
+			}
+		}
+	}
+
+	ret := out.String()
+	scanned := append([]JSONRepair{}, r.repairs...)
+	if inString {
+		r.add(JSONRepairCloseString)
+		ret += `"`
+	}
+	if candidate := r.close(completeValue(r, ret), string(stack)); json.Valid([]byte(candidate)) {
+		return candidate, r.repairs, nil
+	}
+
+	// drop the last member of truncated data when it is incomplete, for example `{"a": 1, "b`.
+	// The members before it are complete, so they are never dropped.
+	if truncated := inString || len(stack) > 0; truncated && len(cutPoints) > 0 {
+		cp := cutPoints[len(cutPoints)-1]
+		tmp := &jsonRepairer{repairs: append([]JSONRepair{}, scanned...)}
+		tmp.add(JSONRepairDropIncompleteMember)
+		candidate := tmp.close(ret[:cp.length], cp.stack)
+		if json.Valid([]byte(candidate)) {
+			return candidate, tmp.repairs, nil
+		}
+	}
+	return data, nil, xerrors.Errorf("failed to repair json: %w", ErrParseFunctionCallingArguments)
+}
+
+func (r *jsonRepairer) stripCodeFence(s string) string {
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	r.add(JSONRepairStripCodeFence)
+	// drop the opening fence line such as ```json
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	} else {
+		s = strings.TrimLeft(s, "`")
+	}
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "```")
+	return strings.TrimSpace(s)
+}
+
+func (r *jsonRepairer) close(s, stack string) string {
+	s = strings.TrimRight(s, " \t\r\n")
+	for strings.HasSuffix(s, ",") {
+		r.add(JSONRepairRemoveTrailingComma)
+		s = strings.TrimRight(strings.TrimSuffix(s, ","), " \t\r\n")
+	}
+	b := new(strings.Builder)
+	b.WriteString(s)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			r.add(JSONRepairCloseObject)
+			b.WriteByte('}')
+		} else {
+			r.add(JSONRepairCloseArray)
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
+
+// complete a value cut off after a colon or in the middle of a literal
+func completeValue(r *jsonRepairer, s string) string {
+	trimmed := strings.TrimRight(s, " \t\r\n")
+	if strings.HasSuffix(trimmed, ":") {
+		r.add(JSONRepairCompleteValue)
+		return trimmed + "null"
+	}
+	for _, literal := range []string{"true", "false", "null"} {
+		for i := 1; i < len(literal); i++ {
+			prefix := literal[:i]
+			if strings.HasSuffix(trimmed, prefix) && endsWithDelimiter(strings.TrimSuffix(trimmed, prefix)) {
+				r.add(JSONRepairCompleteValue)
+				return trimmed + literal[i:]
+			}
+		}
+	}
+	return s
+}
+
+func endsWithDelimiter(s string) bool {
+	s = strings.TrimRight(s, " \t\r\n")
+	return strings.HasSuffix(s, ":") || strings.HasSuffix(s, ",") || strings.HasSuffix(s, "[")
+}
+
+// a quote closes the string when it is followed by a json delimiter or the end of data.
+// In an object, a comma must be followed by the next key, so that "he said "stop", then left" is one string.
+func closesString(rest string, inObject bool) bool {
+	rest = strings.TrimLeft(rest, " \t\r\n")
+	if rest == "" {
+		return true
+	}
+	switch rest[0] {
+	case '}', ']', ':':
+		return true
+	case ',':
+		next := strings.TrimLeft(rest[1:], " \t\r\n")
+		return !inObject || next == "" || next[0] == '"'
+	}
+	return false
+}
+
+func openingBracket(close byte) byte {
+	if close == '}' {
+		return '{'
+	}
+	return '['
+}
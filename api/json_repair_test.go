@@ -0,0 +1,133 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		repairs []JSONRepair
+	}{
+		{
+			name: "valid",
+			data: `{"a": 1}`,
+			want: `{"a": 1}`,
+		},
+		{
+			name:    "code fence",
+			data:    "```json\n{\"a\": 1}\n```",
+			want:    `{"a": 1}`,
+			repairs: []JSONRepair{JSONRepairStripCodeFence},
+		},
+		{
+			name:    "trailing comma",
+			data:    `{"a": [1, 2,], "b": 3,}`,
+			want:    `{"a": [1, 2], "b": 3}`,
+			repairs: []JSONRepair{JSONRepairRemoveTrailingComma},
+		},
+		{
+			name:    "unescaped quotes followed by a comma",
+			data:    `{"b": "he said "stop", then left"}`,
+			want:    `{"b": "he said \"stop\", then left"}`,
+			repairs: []JSONRepair{JSONRepairEscapeQuote},
+		},
+		{
+			name:    "unescaped quotes",
+			data:    `{"a": "the "best" one", "b": 1}`,
+			want:    `{"a": "the \"best\" one", "b": 1}`,
+			repairs: []JSONRepair{JSONRepairEscapeQuote},
+		},
+		{
+			name:    "control character",
+			data:    "{\"a\": \"x\ny\"}",
+			want:    `{"a": "x\ny"}`,
+			repairs: []JSONRepair{JSONRepairEscapeControlChar},
+		},
+		{
+			name:    "unterminated string",
+			data:    `{"a": "xy`,
+			want:    `{"a": "xy"}`,
+			repairs: []JSONRepair{JSONRepairCloseString, JSONRepairCloseObject},
+		},
+		{
+			name:    "unterminated array",
+			data:    `{"a": [1, 2`,
+			want:    `{"a": [1, 2]}`,
+			repairs: []JSONRepair{JSONRepairCloseArray, JSONRepairCloseObject},
+		},
+		{
+			name:    "incomplete literal",
+			data:    `{"a": tr`,
+			want:    `{"a": true}`,
+			repairs: []JSONRepair{JSONRepairCompleteValue, JSONRepairCloseObject},
+		},
+		{
+			name:    "missing value",
+			data:    `{"a": 1, "b":`,
+			want:    `{"a": 1, "b":null}`,
+			repairs: []JSONRepair{JSONRepairCompleteValue, JSONRepairCloseObject},
+		},
+		{
+			name:    "incomplete key",
+			data:    `{"a": 1, "b`,
+			want:    `{"a": 1}`,
+			repairs: []JSONRepair{JSONRepairDropIncompleteMember, JSONRepairCloseObject},
+		},
+		{
+			name:    "unmatched closing brace",
+			data:    `{"a":"b"}}`,
+			want:    `{"a":"b"}`,
+			repairs: []JSONRepair{JSONRepairDropUnmatchedBracket},
+		},
+		{
+			name:    "unmatched closing bracket",
+			data:    `{"a": [1, 2]]}`,
+			want:    `{"a": [1, 2]}`,
+			repairs: []JSONRepair{JSONRepairDropUnmatchedBracket},
+		},
+		{
+			name:    "missing closing bracket",
+			data:    `{"a": [1, 2}`,
+			want:    `{"a": [1, 2]}`,
+			repairs: []JSONRepair{JSONRepairCloseArray},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, repairs, err := RepairJSON(tt.data)
+			if err != nil {
+				t.Fatalf("RepairJSON(%q) error: %v", tt.data, err)
+			}
+			if got != tt.want {
+				t.Errorf("RepairJSON(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+			if !reflect.DeepEqual(repairs, tt.repairs) {
+				t.Errorf("RepairJSON(%q) repairs = %v, want %v", tt.data, repairs, tt.repairs)
+			}
+		})
+	}
+}
+
+// complete members must not be dropped to make the json valid
+func TestRepairJSONError(t *testing.T) {
+	tests := []string{
+		`{"a": 1, "b": 2 x}`,
+		`{"a": 1} x`,
+		`{"a" 1, "b": 2}`,
+		`not json`,
+	}
+	for _, data := range tests {
+		t.Run(data, func(t *testing.T) {
+			got, repairs, err := RepairJSON(data)
+			if !xerrors.Is(err, ErrParseFunctionCallingArguments) {
+				t.Fatalf("RepairJSON(%q) = %q, %v, %v, want ErrParseFunctionCallingArguments", data, got, repairs, err)
+			}
+		})
+	}
+}
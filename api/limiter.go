@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// requests and tokens per minute, 0 means unlimited
+type LimiterBudget struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// client-side rate limiter for ChatCompletionsV1 and CompletionsV1, including the streaming variants.
+// Each model has a token bucket for requests and one for tokens which refill continuously.
+// A request consumes the estimated prompt tokens plus max_tokens (or max_completion_tokens),
+// and the estimate is reconciled when the call ends, with the usage of the response or of the last chunk of a stream.
+// The tokens of a call which fails are refunded.
+// When the budget is exhausted the request waits, unless the deadline of the context
+// is earlier than the time the budget is available, then ErrLimiterBudgetExceeded is returned immediately.
+type Limiter struct {
+	Budgets map[string]LimiterBudget // by model
+	Default *LimiterBudget           // for models which are not in Budgets, nil means unlimited
+
+	// estimate the prompt tokens. default: about 4 characters per token
+	EstimateChatTokens        func(input *ChatCompletionsV1Input) int
+	EstimateCompletionsTokens func(input *CompletionsV1Input) int
+
+	mu      sync.Mutex
+	buckets map[string]*limiterBuckets
+}
+
+func NewLimiter(budgets map[string]LimiterBudget) *Limiter {
+	return &Limiter{
+		Budgets: budgets,
+	}
+}
+
+type tokenBucket struct {
+	capacity float64
+	tokens   float64 // can be negative after reconciliation
+	rate     float64 // per second
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b == nil {
+		return
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// time until n tokens are available. n is capped to the capacity so that a large request can be sent with a full bucket.
+func (b *tokenBucket) wait(n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	n = math.Min(n, b.capacity)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) take(n float64) {
+	if b == nil {
+		return
+	}
+	b.tokens -= n
+}
+
+type limiterBuckets struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+func (l *Limiter) budget(model string) (LimiterBudget, bool) {
+	if b, ok := l.Budgets[model]; ok {
+		return b, true
+	}
+	if l.Default != nil {
+		return *l.Default, true
+	}
+	return LimiterBudget{}, false
+}
+
+// must be called with l.mu held
+func (l *Limiter) bucketsFor(model string, now time.Time) *limiterBuckets {
+	if l.buckets == nil {
+		l.buckets = map[string]*limiterBuckets{}
+	}
+	if b, ok := l.buckets[model]; ok {
+		return b
+	}
+	budget, ok := l.budget(model)
+	if !ok {
+		return nil
+	}
+	b := &limiterBuckets{
+		requests: newTokenBucket(budget.RequestsPerMinute, now),
+		tokens:   newTokenBucket(budget.TokensPerMinute, now),
+	}
+	l.buckets[model] = b
+	return b
+}
+
+// wait until a request with the estimated tokens fits in the budget of the model
+func (l *Limiter) wait(ctx context.Context, model string, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		b := l.bucketsFor(model, now)
+		if b == nil {
+			l.mu.Unlock()
+			return nil
+		}
+		b.requests.refill(now)
+		b.tokens.refill(now)
+		d := b.requests.wait(1)
+		if td := b.tokens.wait(float64(tokens)); td > d {
+			d = td
+		}
+		if d == 0 {
+			b.requests.take(1)
+			b.tokens.take(float64(tokens))
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(d)) {
+			return xerrors.Errorf("model: %s, wait: %s, error: %w", model, d, ErrLimiterBudgetExceeded)
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// replace the estimated tokens with the tokens actually used, 0 to refund a failed call
+func (l *Limiter) reconcile(model string, estimated, actual int) {
+	if l == nil || actual == estimated {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[model]
+	if !ok {
+		return
+	}
+	b.tokens.refill(time.Now())
+	b.tokens.take(float64(actual - estimated))
+}
+
+func maxTokens(maxTokens *int, n int) int {
+	if maxTokens == nil {
+		return 0
+	}
+	if n > 1 {
+		return *maxTokens * n
+	}
+	return *maxTokens
+}
+
+func (l *Limiter) estimateChat(input *ChatCompletionsV1Input) int {
+	if l == nil {
+		return 0
+	}
+	return l.chatPromptTokens(input) + chatMaxTokens(input)
+}
+
+// prompt tokens with EstimateChatTokens, l can be nil
+func (l *Limiter) chatPromptTokens(input *ChatCompletionsV1Input) int {
+	if l != nil && l.EstimateChatTokens != nil {
+		return l.EstimateChatTokens(input)
+	}
+	return estimateChatTokens(input)
+}
+
+func chatMaxTokens(input *ChatCompletionsV1Input) int {
+	if input.MaxCompletionTokens != nil {
+		return maxTokens(input.MaxCompletionTokens, input.N)
+	}
+	return maxTokens(input.MaxTokens, input.N)
+}
+
+func (l *Limiter) estimateCompletions(input *CompletionsV1Input) int {
+	if l == nil {
+		return 0
+	}
+	return l.completionsPromptTokens(input) + completionsMaxTokens(input)
+}
+
+// prompt tokens with EstimateCompletionsTokens, l can be nil
+func (l *Limiter) completionsPromptTokens(input *CompletionsV1Input) int {
+	if l != nil && l.EstimateCompletionsTokens != nil {
+		return l.EstimateCompletionsTokens(input)
+	}
+	b, _ := json.Marshal(input.Prompt)
+	return estimateTextTokens(string(b))
+}
+
+func completionsMaxTokens(input *CompletionsV1Input) int {
+	n := 1
+	if input.N != nil {
+		n = *input.N
+	}
+	return maxTokens(input.MaxTokens, n)
+}
+
+func estimateTextTokens(s string) int {
+	return (len([]rune(s)) + 3) / 4
+}
+
+// rough estimate without a tokenizer
+func estimateChatTokens(input *ChatCompletionsV1Input) int {
+	ret := 3 // every reply is primed with <|start|>assistant<|message|>
+	for _, m := range input.Messages {
+		ret += 4 + estimateTextTokens(m.Role) + estimateTextTokens(m.Content) + estimateTextTokens(m.Name)
+		for _, tc := range m.ToolCalls {
+			if tc.Function == nil {
+				continue
+			}
+			ret += estimateTextTokens(tc.Function.Name) + estimateTextTokens(tc.Function.Arguments)
+		}
+	}
+	if len(input.Tools) > 0 {
+		b, _ := json.Marshal(input.Tools)
+		ret += estimateTextTokens(string(b))
+	}
+	if len(input.Functions) > 0 {
+		b, _ := json.Marshal(input.Functions)
+		ret += estimateTextTokens(string(b))
+	}
+	return ret
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/config"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+// a context whose deadline is earlier than any refill of a budget per minute
+func shortDeadline(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestLimiterWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *Limiter
+		model   string
+		tokens  []int // of each call, only the last one can fail
+		wantErr bool
+	}{
+		{
+			name:    "within the requests",
+			limiter: NewLimiter(map[string]LimiterBudget{"gpt-4o": {RequestsPerMinute: 2}}),
+			model:   "gpt-4o",
+			tokens:  []int{0, 0},
+		},
+		{
+			name:    "over the requests",
+			limiter: NewLimiter(map[string]LimiterBudget{"gpt-4o": {RequestsPerMinute: 2}}),
+			model:   "gpt-4o",
+			tokens:  []int{0, 0, 0},
+			wantErr: true,
+		},
+		{
+			name:    "within the tokens",
+			limiter: NewLimiter(map[string]LimiterBudget{"gpt-4o": {TokensPerMinute: 100}}),
+			model:   "gpt-4o",
+			tokens:  []int{60, 40},
+		},
+		{
+			name:    "over the tokens",
+			limiter: NewLimiter(map[string]LimiterBudget{"gpt-4o": {TokensPerMinute: 100}}),
+			model:   "gpt-4o",
+			tokens:  []int{60, 41},
+			wantErr: true,
+		},
+		{
+			name:    "larger than the capacity with a full bucket",
+			limiter: NewLimiter(map[string]LimiterBudget{"gpt-4o": {TokensPerMinute: 100}}),
+			model:   "gpt-4o",
+			tokens:  []int{1000},
+		},
+		{
+			name:    "model without a budget",
+			limiter: NewLimiter(map[string]LimiterBudget{"gpt-4o": {RequestsPerMinute: 1}}),
+			model:   "gpt-4o-mini",
+			tokens:  []int{0, 0, 0},
+		},
+		{
+			name:    "default budget",
+			limiter: &Limiter{Default: &LimiterBudget{RequestsPerMinute: 1}},
+			model:   "gpt-4o-mini",
+			tokens:  []int{0, 0},
+			wantErr: true,
+		},
+		{
+			name:   "nil limiter",
+			model:  "gpt-4o",
+			tokens: []int{1000, 1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			for i, tokens := range tt.tokens {
+				err = tt.limiter.wait(shortDeadline(t), tt.model, tokens)
+				if err != nil && i < len(tt.tokens)-1 {
+					t.Fatalf("call %d error: %v", i, err)
+				}
+			}
+			if tt.wantErr && !xerrors.Is(err, ErrLimiterBudgetExceeded) {
+				t.Errorf("error = %v, want ErrLimiterBudgetExceeded", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLimiterReconcile(t *testing.T) {
+	tests := []struct {
+		name    string
+		actual  int // of the first call which is estimated at 60 tokens
+		wantErr bool
+	}{
+		{name: "as estimated", actual: 60, wantErr: true},
+		{name: "refunded", actual: 0},
+		{name: "fewer tokens", actual: 20},
+		{name: "more tokens", actual: 90, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(map[string]LimiterBudget{"gpt-4o": {TokensPerMinute: 100}})
+			if err := l.wait(shortDeadline(t), "gpt-4o", 60); err != nil {
+				t.Fatal(err)
+			}
+			l.reconcile("gpt-4o", 60, tt.actual)
+			err := l.wait(shortDeadline(t), "gpt-4o", 60)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// the tokens of a call which fails are refunded
+func TestLimiterRefundFailedCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"message": "internal error"}}`, http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	l := NewLimiter(map[string]LimiterBudget{"gpt-4o": {TokensPerMinute: 1000}})
+	ai := New(&config.Configuration{ApiKey: lo.ToPtr("key"), Endpoint: lo.ToPtr(srv.URL)}, WithLimiter(l))
+	input := &ChatCompletionsV1Input{
+		Model:     lo.ToPtr("gpt-4o"),
+		Messages:  []Message{{Role: "user", Content: "hello"}},
+		MaxTokens: lo.ToPtr(600),
+	}
+	for i := 0; i < 3; i++ {
+		_, err := ai.ChatCompletionsV1WithContext(shortDeadline(t), input)
+		if err == nil || xerrors.Is(err, ErrLimiterBudgetExceeded) {
+			t.Fatalf("call %d error = %v, want the error of the server", i, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// json fields of the request and response bodies redacted by default
+var DefaultRedactFields = []string{"content", "refusal", "prompt", "text", "arguments"}
+
+const redacted = "[REDACTED]"
+
+// headers whose values are always redacted
+var redactHeaders = []string{"Authorization", "Api-Key", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// api keys such as sk-... and sk-proj-..., always redacted from the logs
+var apiKeyPattern = regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}`)
+
+// log each call with the endpoint, the model, the status, the latency, the token usage and the request id.
+// Failed calls are logged at the error level, the others at the info level.
+// The request and the response of each round trip are logged at the debug level,
+// with the credential headers, api keys and the fields set by WithLogRedactFields redacted.
+// The bodies of multipart requests and event streams are not logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(api *OpenAIAPI) {
+		api.logger = l
+	}
+}
+
+// json fields of the bodies redacted in the debug logs. default: DefaultRedactFields.
+// Call it with no fields to log the bodies as they are, except for the api keys.
+func WithLogRedactFields(fields ...string) Option {
+	return func(api *OpenAIAPI) {
+		api.logRedactFields = map[string]bool{}
+		for _, f := range fields {
+			api.logRedactFields[f] = true
+		}
+	}
+}
+
+// the innermost interceptor, so that it logs the requests as they are sent
+func (api *OpenAIAPI) loggingInterceptor() *Interceptor {
+	if api.logRedactFields == nil {
+		api.logRedactFields = map[string]bool{}
+		for _, f := range DefaultRedactFields {
+			api.logRedactFields[f] = true
+		}
+	}
+	return &Interceptor{
+		Call:      api.logCall,
+		RoundTrip: api.logRoundTrip,
+	}
+}
+
+func (api *OpenAIAPI) logCall(ctx context.Context, call *Call, next CallHandler) (any, error) {
+	start := time.Now()
+	output, err := next(ctx, call)
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", call.Endpoint),
+	}
+	if model := call.Model(); model != "" {
+		attrs = append(attrs, slog.String("model", model))
+	}
+	md := OutputResponseMetadata(output)
+	if md == nil {
+		md, _ = ResponseMetadataFromError(err)
+	}
+	if md != nil {
+		attrs = append(attrs, slog.Int("status", md.StatusCode), slog.Duration("latency", md.Latency))
+		if md.RequestID != "" {
+			attrs = append(attrs, slog.String("request_id", md.RequestID))
+		}
+	} else if call.Response != nil {
+		attrs = append(attrs, slog.Int("status", call.Response.StatusCode), slog.Duration("latency", time.Since(start)))
+	}
+	if usage := OutputUsage(output); usage != nil {
+		attrs = append(attrs, slog.Group("usage",
+			slog.Int("input_tokens", usage.PromptTokens),
+			slog.Int("output_tokens", usage.CompletionTokens),
+			slog.Int("total_tokens", usage.TotalTokens),
+		))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", apiKeyPattern.ReplaceAllString(err.Error(), redacted)))
+		api.logger.LogAttrs(ctx, slog.LevelError, "openai call failed", attrs...)
+	} else {
+		api.logger.LogAttrs(ctx, slog.LevelInfo, "openai call", attrs...)
+	}
+	return output, err
+}
+
+func (api *OpenAIAPI) logRoundTrip(call *Call, req *http.Request, next RoundTripHandler) (*http.Response, error) {
+	ctx := req.Context()
+	if !api.logger.Enabled(ctx, slog.LevelDebug) {
+		return next(req)
+	}
+	attrs := []slog.Attr{
+		slog.String("endpoint", call.Endpoint),
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.Any("header", redactHeader(req.Header)),
+	}
+	if req.GetBody != nil && isJSON(req.Header) {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(body)
+			body.Close()
+			attrs = append(attrs, slog.String("body", api.redactBody(b)))
+		}
+	}
+	api.logger.LogAttrs(ctx, slog.LevelDebug, "openai request", attrs...)
+
+	resp, err := next(req)
+	if err != nil {
+		return resp, err
+	}
+	attrs = []slog.Attr{
+		slog.String("endpoint", call.Endpoint),
+		slog.Int("status", resp.StatusCode),
+		slog.Any("header", redactHeader(resp.Header)),
+	}
+	if isJSON(resp.Header) {
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		// the caller reads the body again
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		if err != nil {
+			return resp, err
+		}
+		attrs = append(attrs, slog.String("body", api.redactBody(b)))
+	}
+	api.logger.LogAttrs(ctx, slog.LevelDebug, "openai response", attrs...)
+	return resp, nil
+}
+
+func isJSON(h http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+func redactHeader(h http.Header) http.Header {
+	ret := h.Clone()
+	for _, k := range redactHeaders {
+		if _, ok := ret[k]; ok {
+			ret.Set(k, redacted)
+		}
+	}
+	for k, values := range ret {
+		for i, v := range values {
+			values[i] = apiKeyPattern.ReplaceAllString(v, redacted)
+		}
+		ret[k] = values
+	}
+	return ret
+}
+
+// redact the fields of a json body and the api keys in it
+func (api *OpenAIAPI) redactBody(b []byte) string {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		// the fields can not be found
+		return redacted
+	}
+	b, err := json.Marshal(api.redactValue(v))
+	if err != nil {
+		return redacted
+	}
+	return apiKeyPattern.ReplaceAllString(string(b), redacted)
+}
+
+func (api *OpenAIAPI) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if api.logRedactFields[k] && field != nil {
+				v[k] = redacted
+			} else {
+				v[k] = api.redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = api.redactValue(item)
+		}
+	}
+	return v
+}
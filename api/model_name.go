@@ -0,0 +1,31 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+)
+
+// the date of a snapshot such as -2024-08-06
+var snapshotDate = regexp.MustCompile(`-\d{4}-\d{2}-\d{2}$`)
+
+// look up a model in a table keyed by model name, such as MaxOutputTokens.
+// A dated snapshot such as gpt-4o-2024-08-06 uses its own entry if any, otherwise the entry of gpt-4o.
+// Other models, such as o1-mini when only o1 is in the table, are not found.
+func LookupModel[V any](table map[string]V, model string) (V, bool) {
+	if v, ok := table[model]; ok {
+		return v, true
+	}
+	v, ok := table[snapshotDate.ReplaceAllString(model, "")]
+	return v, ok
+}
+
+// the base model of a fine-tuned model, such as gpt-4o-mini-2024-07-18 for ft:gpt-4o-mini-2024-07-18:org::id.
+// It returns false for the other models.
+func FineTunedBaseModel(model string) (string, bool) {
+	rest, ok := strings.CutPrefix(model, "ft:")
+	if !ok {
+		return "", false
+	}
+	base, _, _ := strings.Cut(rest, ":")
+	return base, true
+}
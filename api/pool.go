@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/config"
+	"golang.org/x/xerrors"
+)
+
+// a set of credentials and an endpoint in a Pool
+type PoolMember struct {
+	Name        string
+	Credentials config.CredentialsProvider
+	Endpoint    *string // default: the endpoint of the configuration. Only the scheme and the host are used, the path is the one of the configuration
+
+	mu                sync.Mutex
+	inflight          int
+	limitRequests     int
+	remainingRequests int
+	resetRequestsAt   time.Time
+	limitTokens       int
+	remainingTokens   int
+	resetTokensAt     time.Time
+	ejectedUntil      time.Time
+}
+
+func NewPoolMember(name string, credentials config.CredentialsProvider, endpoint *string) (*PoolMember, error) {
+	if credentials == nil {
+		return nil, xerrors.Errorf("pool member: %s: %w", name, ErrNoCredentialsProvider)
+	}
+	return &PoolMember{
+		Name:        name,
+		Credentials: credentials,
+		Endpoint:    endpoint,
+	}, nil
+}
+
+// remaining fraction of the request and token budgets, 1 if unknown
+func (m *PoolMember) capacity(now time.Time) float64 {
+	ret := 1.0
+	if m.limitRequests > 0 && now.Before(m.resetRequestsAt) {
+		ret = math.Min(ret, float64(m.remainingRequests)/float64(m.limitRequests))
+	}
+	if m.limitTokens > 0 && now.Before(m.resetTokensAt) {
+		ret = math.Min(ret, float64(m.remainingTokens)/float64(m.limitTokens))
+	}
+	return ret
+}
+
+func (m *PoolMember) healthy(now time.Time) bool {
+	return !now.Before(m.ejectedUntil)
+}
+
+// Healthy reports whether the member is not ejected
+func (m *PoolMember) Healthy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.healthy(time.Now())
+}
+
+func (m *PoolMember) apply(req *http.Request) error {
+	if m.Endpoint != nil {
+		u, err := url.Parse(*m.Endpoint)
+		if err != nil {
+			return xerrors.Errorf("pool member: %s: %w", m.Name, err)
+		}
+		req.URL.Scheme = u.Scheme
+		req.URL.Host = u.Host
+		req.Host = ""
+	}
+	credentials, err := m.Credentials.Credentials(req.Context())
+	if err != nil {
+		return xerrors.Errorf("pool member: %s: failed to resolve credentials: %w", m.Name, err)
+	}
+	if credentials == nil || credentials.ApiKey == "" {
+		return xerrors.Errorf("pool member: %s: no token", m.Name)
+	}
+	req.Header.Set("Authorization", "Bearer "+credentials.ApiKey)
+	req.Header.Del("OpenAI-Organization")
+	req.Header.Del("OpenAI-Project")
+	if credentials.Organization != "" {
+		req.Header.Set("OpenAI-Organization", credentials.Organization)
+	}
+	if credentials.Project != "" {
+		req.Header.Set("OpenAI-Project", credentials.Project)
+	}
+	return nil
+}
+
+func (m *PoolMember) observe(resp *http.Response, now time.Time, ejectDuration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rl := ParseRateLimit(resp.Header); rl != nil {
+		if rl.LimitRequests > 0 {
+			m.limitRequests = rl.LimitRequests
+			m.remainingRequests = rl.RemainingRequests
+			m.resetRequestsAt = now.Add(rl.ResetRequests)
+		}
+		if rl.LimitTokens > 0 {
+			m.limitTokens = rl.LimitTokens
+			m.remainingTokens = rl.RemainingTokens
+			m.resetTokensAt = now.Add(rl.ResetTokens)
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		m.ejectedUntil = now.Add(ejectDuration)
+	case http.StatusTooManyRequests:
+		// peek the body to distinguish insufficient_quota from rate limits
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		if bytes.Contains(b, []byte("insufficient_quota")) {
+			m.ejectedUntil = now.Add(ejectDuration)
+		}
+	}
+}
+
+// spreads requests across several credentials and endpoints.
+// Each request is routed to the healthy member with the most remaining requests and tokens
+// according to the x-ratelimit-* headers. Members returning 401 or insufficient_quota are
+// ejected for EjectDuration.
+// The pool does not support the Azure mode, New fails when both are set.
+type Pool struct {
+	Members       []*PoolMember
+	EjectDuration time.Duration // default: 1 minute
+
+	mu   sync.Mutex
+	next int
+}
+
+func NewPool(members ...*PoolMember) *Pool {
+	return &Pool{
+		Members: members,
+	}
+}
+
+func (p *Pool) ejectDuration() time.Duration {
+	if p.EjectDuration <= 0 {
+		return time.Minute
+	}
+	return p.EjectDuration
+}
+
+func (p *Pool) acquire() (*PoolMember, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+
+	var best *PoolMember
+	bestScore := -1.0
+	// start from a rotating offset so that ties are spread in round robin
+	for i := 0; i < len(p.Members); i++ {
+		m := p.Members[(p.next+i)%len(p.Members)]
+		m.mu.Lock()
+		if m.healthy(now) {
+			score := m.capacity(now) / float64(1+m.inflight)
+			if score > bestScore {
+				best = m
+				bestScore = score
+			}
+		}
+		m.mu.Unlock()
+	}
+	if best == nil {
+		return nil, ErrNoAvailablePoolMember
+	}
+	p.next++
+	best.mu.Lock()
+	best.inflight++
+	best.mu.Unlock()
+	return best, nil
+}
+
+func (p *Pool) release(m *PoolMember) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inflight--
+}
+
+func (p *Pool) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	m, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(m)
+	if err := m.apply(req); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	m.observe(resp, time.Now(), p.ejectDuration())
+	return resp, nil
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// parsed x-ratelimit-* response headers.
+// doc: https://platform.openai.com/docs/guides/rate-limits#rate-limits-in-headers
+type RateLimit struct {
+	LimitRequests     int
+	RemainingRequests int
+	ResetRequests     time.Duration // time until the request limit resets
+	LimitTokens       int
+	RemainingTokens   int
+	ResetTokens       time.Duration // time until the token limit resets
+}
+
+// parse the x-ratelimit-* headers. It returns nil if none of them is present.
+func ParseRateLimit(h http.Header) *RateLimit {
+	ret := &RateLimit{}
+	found := false
+	parseInt := func(name string, dst *int) {
+		if v := h.Get(name); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				*dst = n
+				found = true
+			}
+		}
+	}
+	parseDuration := func(name string, dst *time.Duration) {
+		if v := h.Get(name); v != "" {
+			if d, err := time.ParseDuration(v); err == nil {
+				*dst = d
+				found = true
+			}
+		}
+	}
+	parseInt("x-ratelimit-limit-requests", &ret.LimitRequests)
+	parseInt("x-ratelimit-remaining-requests", &ret.RemainingRequests)
+	parseDuration("x-ratelimit-reset-requests", &ret.ResetRequests)
+	parseInt("x-ratelimit-limit-tokens", &ret.LimitTokens)
+	parseInt("x-ratelimit-remaining-tokens", &ret.RemainingTokens)
+	parseDuration("x-ratelimit-reset-tokens", &ret.ResetTokens)
+	if !found {
+		return nil
+	}
+	return ret
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/danielgtaylor/huma/schema"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+const (
+	ResponseFormatTypeText       = "text"
+	ResponseFormatTypeJSONObject = "json_object"
+	ResponseFormatTypeJSONSchema = "json_schema"
+)
+
+type ResponseFormatJSONSchema struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Schema      *schema.Schema `json:"schema,omitempty"`
+	Strict      *bool          `json:"strict,omitempty"`
+}
+
+// doc: https://platform.openai.com/docs/guides/structured-outputs
+type ResponseFormat struct {
+	Type       string                    `json:"type"`
+	JSONSchema *ResponseFormatJSONSchema `json:"json_schema,omitempty"`
+}
+
+// generate a strict json_schema response format from the type of v
+// example:
+//
+//	type answer struct {
+//	    Name string `json:"name"`
+//	    Age  int    `json:"age"`
+//	}
+//
+// NewJSONSchemaResponseFormat("answer", "description", answer{})
+func NewJSONSchemaResponseFormat(name, description string, v any) (*ResponseFormat, error) {
+	s, err := GenerateStrictSchema(reflect.TypeOf(v))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to generate schema: %w", err)
+	}
+	return &ResponseFormat{
+		Type: ResponseFormatTypeJSONSchema,
+		JSONSchema: &ResponseFormatJSONSchema{
+			Name:        name,
+			Description: description,
+			Schema:      s,
+			Strict:      lo.ToPtr(true),
+		},
+	}, nil
+}
+
+// returned when the model refuses to answer a structured output request
+type RefusalError struct {
+	Refusal string
+}
+
+func (e *RefusalError) Error() string {
+	return "refusal: " + e.Refusal
+}
+
+// decode the content of the first choice into v
+// If the model refused, *RefusalError is returned.
+func (impl *ChatCompletionsV1Output) DecodeContent(v any) error {
+	if len(impl.Choices) == 0 {
+		return xerrors.New("choices is empty")
+	}
+	msg := impl.Choices[0].Message
+	if msg.Refusal != nil && *msg.Refusal != "" {
+		return &RefusalError{Refusal: *msg.Refusal}
+	}
+	if msg.Content == nil {
+		return xerrors.New("content is empty")
+	}
+	if err := json.Unmarshal([]byte(*msg.Content), v); err != nil {
+		return xerrors.Errorf("failed to decode content: %w", err)
+	}
+	return nil
+}
+
+// call ChatCompletionsV1 with a strict json_schema response format generated from T
+// and decode the first choice into T.
+// If the model refused, *RefusalError is returned.
+func ChatCompletionsV1Structured[T any](ctx context.Context, api OpenAIAPIIface, name string, input *ChatCompletionsV1Input) (*T, *ChatCompletionsV1Output, error) {
+	var zero T
+	format, err := NewJSONSchemaResponseFormat(name, "", zero)
+	if err != nil {
+		return nil, nil, err
+	}
+	req := *input
+	req.ResponseFormat = format
+	output, err := api.ChatCompletionsV1WithContext(ctx, &req)
+	if err != nil {
+		return nil, output, err
+	}
+	ret := new(T)
+	if err := output.DecodeContent(ret); err != nil {
+		return nil, output, err
+	}
+	return ret, output, nil
+}
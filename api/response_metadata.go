@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// metadata of the http response, set on every output once a response is received
+type ResponseMetadata struct {
+	StatusCode     int
+	RequestID      string        // x-request-id, include it in support tickets
+	Model          string        // openai-model
+	Organization   string        // openai-organization
+	ProcessingTime time.Duration // openai-processing-ms
+	Latency        time.Duration // measured by the client, until the response headers are received
+	RateLimit      *RateLimit    // nil if the x-ratelimit-* headers are not present
+	Header         http.Header
+}
+
+func newResponseMetadata(resp *http.Response, latency time.Duration) *ResponseMetadata {
+	ret := &ResponseMetadata{
+		StatusCode:   resp.StatusCode,
+		RequestID:    resp.Header.Get("x-request-id"),
+		Model:        resp.Header.Get("openai-model"),
+		Organization: resp.Header.Get("openai-organization"),
+		Latency:      latency,
+		RateLimit:    ParseRateLimit(resp.Header),
+		Header:       resp.Header,
+	}
+	if v := resp.Header.Get("openai-processing-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil {
+			ret.ProcessingTime = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	return ret
+}
+
+// returned by the streaming methods when the status is not 200.
+// It wraps the same errors as the other methods.
+type ResponseError struct {
+	ResponseMetadata *ResponseMetadata
+	Err              error
+}
+
+func (e *ResponseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// get the response metadata from an error returned by a streaming method
+func ResponseMetadataFromError(err error) (*ResponseMetadata, bool) {
+	var re *ResponseError
+	if xerrors.As(err, &re) {
+		return re.ResponseMetadata, true
+	}
+	return nil, false
+}
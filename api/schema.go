@@ -0,0 +1,208 @@
+package api
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/schema"
+	"golang.org/x/xerrors"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generate a json schema compatible with the OpenAI strict mode.
+// doc: https://platform.openai.com/docs/guides/structured-outputs#supported-schemas
+//
+// Every object has additionalProperties false and lists all of its properties in required.
+// Pointer fields and fields tagged with omitempty become nullable with anyOf.
+// The following struct tags are supported: description (or doc), enum, format, minimum and maximum.
+// Maps, interfaces, channels, functions, complex numbers and recursive types return ErrUnsupportedSchema.
+func GenerateStrictSchema(t reflect.Type) (*schema.Schema, error) {
+	if t == nil {
+		return nil, xerrors.Errorf("type is nil: %w", ErrUnsupportedSchema)
+	}
+	return generateStrictSchema(t, map[reflect.Type]bool{})
+}
+
+func generateStrictSchema(t reflect.Type, visiting map[reflect.Type]bool) (*schema.Schema, error) {
+	switch t {
+	case timeType:
+		return &schema.Schema{Type: schema.TypeString, Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return generateStrictSchema(t.Elem(), visiting)
+	case reflect.Struct:
+		if visiting[t] {
+			return nil, xerrors.Errorf("recursive type %s: %w", t, ErrUnsupportedSchema)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		ret := &schema.Schema{
+			Type:                 schema.TypeObject,
+			Properties:           map[string]*schema.Schema{},
+			Required:             []string{},
+			AdditionalProperties: false,
+		}
+		if err := generateStrictProperties(t, ret, visiting); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema.Schema{Type: schema.TypeString}, nil
+		}
+		items, err := generateStrictSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &schema.Schema{Type: schema.TypeArray, Items: items}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &schema.Schema{Type: schema.TypeInteger}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema.Schema{Type: schema.TypeInteger, Minimum: schema.F(0)}, nil
+	case reflect.Float32, reflect.Float64:
+		return &schema.Schema{Type: schema.TypeNumber}, nil
+	case reflect.Bool:
+		return &schema.Schema{Type: schema.TypeBoolean}, nil
+	case reflect.String:
+		return &schema.Schema{Type: schema.TypeString}, nil
+	case reflect.Map:
+		return nil, xerrors.Errorf("map %s can not be used with strict mode, use a struct or a slice of key value structs: %w", t, ErrUnsupportedSchema)
+	default:
+		return nil, xerrors.Errorf("type %s: %w", t, ErrUnsupportedSchema)
+	}
+}
+
+func generateStrictProperties(t reflect.Type, s *schema.Schema, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonTags := strings.Split(f.Tag.Get("json"), ",")
+		name := jsonTags[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				if err := generateStrictProperties(et, s, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := s.Properties[name]; ok {
+			continue
+		}
+
+		p, err := generateStrictSchema(f.Type, visiting)
+		if err != nil {
+			return xerrors.Errorf("field %s.%s: %w", t, f.Name, err)
+		}
+		if err := applyStrictTags(f, p); err != nil {
+			return xerrors.Errorf("field %s.%s: %w", t, f.Name, err)
+		}
+
+		nullable := f.Type.Kind() == reflect.Ptr
+		for _, tag := range jsonTags[1:] {
+			if tag == "omitempty" {
+				nullable = true
+			}
+		}
+		if nullable {
+			p = &schema.Schema{
+				Description: p.Description,
+				AnyOf: []*schema.Schema{
+					p,
+					{Type: "null"},
+				},
+			}
+			p.AnyOf[0].Description = ""
+		}
+
+		s.Properties[name] = p
+		s.Required = append(s.Required, name)
+	}
+	return nil
+}
+
+func applyStrictTags(f reflect.StructField, s *schema.Schema) error {
+	if tag, ok := f.Tag.Lookup("description"); ok {
+		s.Description = tag
+	}
+	if tag, ok := f.Tag.Lookup("doc"); ok {
+		s.Description = tag
+	}
+	if tag, ok := f.Tag.Lookup("format"); ok {
+		s.Format = tag
+	}
+	if tag, ok := f.Tag.Lookup("minimum"); ok {
+		v, err := strconv.ParseFloat(tag, 64)
+		if err != nil {
+			return xerrors.Errorf("minimum: %w", err)
+		}
+		s.Minimum = &v
+	}
+	if tag, ok := f.Tag.Lookup("maximum"); ok {
+		v, err := strconv.ParseFloat(tag, 64)
+		if err != nil {
+			return xerrors.Errorf("maximum: %w", err)
+		}
+		s.Maximum = &v
+	}
+	if tag, ok := f.Tag.Lookup("enum"); ok {
+		// enum of a slice applies to its items
+		target := s
+		if s.Type == schema.TypeArray {
+			target = s.Items
+		}
+		for _, v := range strings.Split(tag, ",") {
+			parsed, err := parseEnumValue(target.Type, strings.TrimSpace(v))
+			if err != nil {
+				return err
+			}
+			target.Enum = append(target.Enum, parsed)
+		}
+	}
+	return nil
+}
+
+func parseEnumValue(typ, v string) (any, error) {
+	switch typ {
+	case schema.TypeString:
+		return v, nil
+	case schema.TypeInteger:
+		ret, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("enum: %w", err)
+		}
+		return ret, nil
+	case schema.TypeNumber:
+		ret, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("enum: %w", err)
+		}
+		return ret, nil
+	case schema.TypeBoolean:
+		ret, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, xerrors.Errorf("enum: %w", err)
+		}
+		return ret, nil
+	default:
+		return nil, xerrors.Errorf("enum is not supported for %s: %w", typ, ErrUnsupportedSchema)
+	}
+}
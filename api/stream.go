@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// server-sent events stream of T.
+// example:
+//
+//	stream, err := api.ChatCompletionsV1Stream(ctx, input)
+//	if err != nil {
+//	    return err
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//	    fmt.Print(stream.Current().Content())
+//	}
+//	if err := stream.Err(); err != nil {
+//	    return err
+//	}
+type Stream[T any] struct {
+	body    io.ReadCloser
+	reader  *bufio.Reader
+	current *T
+	err     error
+	done    bool
+
+	responseMetadata *ResponseMetadata
+	onEvent          func(event *T) // called for each event, for example to record the usage
+	onDone           func()         // called once when the stream ends, fails or is closed
+	doneOnce         sync.Once
+}
+
+func newStream[T any](body io.ReadCloser, md *ResponseMetadata) *Stream[T] {
+	return &Stream[T]{
+		body:             body,
+		reader:           bufio.NewReader(body),
+		responseMetadata: md,
+	}
+}
+
+// metadata of the http response that opened the stream
+func (s *Stream[T]) ResponseMetadata() *ResponseMetadata {
+	return s.responseMetadata
+}
+
+// observe the events and the end of the stream, for example in an interceptor.
+// onEvent is called for each event, and onDone once when the stream ends, fails or is closed,
+// with the error of the stream. Either function can be nil. Call it before reading the stream.
+func (s *Stream[T]) Observe(onEvent func(event *T), onDone func(err error)) {
+	if onEvent != nil {
+		prev := s.onEvent
+		s.onEvent = func(event *T) {
+			if prev != nil {
+				prev(event)
+			}
+			onEvent(event)
+		}
+	}
+	if onDone != nil {
+		prev := s.onDone
+		s.onDone = func() {
+			if prev != nil {
+				prev()
+			}
+			onDone(s.err)
+		}
+	}
+}
+
+// advance to the next event. It returns false at the end of the stream or on error.
+func (s *Stream[T]) Next() bool {
+	ret := s.next()
+	if !ret {
+		s.finish()
+	}
+	return ret
+}
+
+func (s *Stream[T]) finish() {
+	s.doneOnce.Do(func() {
+		if s.onDone != nil {
+			s.onDone()
+		}
+	})
+}
+
+func (s *Stream[T]) next() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	data, err := s.readEvent()
+	if err != nil {
+		if err == io.EOF {
+			s.done = true
+			return false
+		}
+		s.err = err
+		return false
+	}
+	if bytes.Equal(data, []byte("[DONE]")) {
+		s.done = true
+		return false
+	}
+
+	errBody := struct {
+		Error *Error `json:"error,omitempty"`
+	}{}
+	if err := json.Unmarshal(data, &errBody); err == nil && errBody.Error != nil {
+		s.err = xerrors.Errorf("msg: %s, error: %w", errBody.Error.Message, ErrUnknown)
+		return false
+	}
+
+	ret := new(T)
+	if err := json.Unmarshal(data, ret); err != nil {
+		s.err = err
+		return false
+	}
+	s.current = ret
+	if s.onEvent != nil {
+		s.onEvent(ret)
+	}
+	return true
+}
+
+// read the data of the next event, joining multi-line data with a newline
+func (s *Stream[T]) readEvent() ([]byte, error) {
+	data := []byte{}
+	hasData := false
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF && hasData {
+				return data, nil
+			}
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if hasData {
+				return data, nil
+			}
+			if err == io.EOF {
+				return nil, err
+			}
+			continue
+		}
+		if value, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			if hasData {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(value, []byte(" "))...)
+			hasData = true
+		}
+		// other fields such as event, id and comments are ignored
+		if err == io.EOF {
+			if hasData {
+				return data, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+func (s *Stream[T]) Current() *T {
+	return s.current
+}
+
+func (s *Stream[T]) Err() error {
+	return s.err
+}
+
+func (s *Stream[T]) Close() error {
+	s.finish()
+	return s.body.Close()
+}
+
+// send a streaming request and return the response body when the status is 200
+func (api *OpenAIAPI) openStream(ctx context.Context, path string, model *string, input any) (io.ReadCloser, *ResponseMetadata, error) {
+	reqBody := new(bytes.Buffer)
+	if err := json.NewEncoder(reqBody).Encode(input); err != nil {
+		return nil, nil, err
+	}
+	endpoint, err := api.requestURL(path, model)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		endpoint.String(),
+		reqBody,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if err := api.setToken(req); err != nil {
+		return nil, nil, err
+	}
+	resp, md, err := api.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, md, &ResponseError{ResponseMetadata: md, Err: streamError(resp)}
+	}
+	return resp.Body, md, nil
+}
+
+// convert a non 200 streaming response into the same errors as the other methods
+func streamError(resp *http.Response) error {
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return xerrors.Errorf("msg: %s, error: %w", buf.String(), ErrUnauthorized)
+	case http.StatusBadGateway:
+		return xerrors.Errorf("msg: %s, error: %w", buf.String(), ErrStatusBadGateway)
+	default:
+		return xerrors.Errorf("status_code: %d, msg: %s, error: %w", resp.StatusCode, buf.String(), ErrUnknown)
+	}
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+type ToolHandler[A any, R any] func(ctx context.Context, args A) (R, error)
+
+type registeredTool struct {
+	function *Function
+	call     func(ctx context.Context, arguments string) (string, error)
+}
+
+// registry of tools that can be called by the model
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	names []string
+	tools map[string]*registeredTool
+}
+
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: map[string]*registeredTool{},
+	}
+}
+
+// register a typed tool handler.
+// The parameters schema is generated from A in the same way as NewFunction,
+// and the arguments are validated against it before the handler is called.
+// If R is a string, it is sent back to the model as is, otherwise it is encoded to json.
+// example:
+//
+//	type weatherArgs struct {
+//	    Location string `json:"location"`
+//	}
+//
+//	RegisterTool(registry, "weather", "get the weather", func(ctx context.Context, args weatherArgs) (string, error) {
+//	    return "sunny", nil
+//	})
+func RegisterTool[A any, R any](registry *ToolRegistry, name, description string, handler ToolHandler[A, R]) error {
+	var zero A
+	f, err := NewFunction(name, description, zero)
+	if err != nil {
+		return err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.tools[name]; ok {
+		return xerrors.Errorf("tool: %s is already registered", name)
+	}
+	registry.names = append(registry.names, name)
+	registry.tools[name] = &registeredTool{
+		function: f,
+		call: func(ctx context.Context, arguments string) (string, error) {
+			var args A
+			if err := decodeArguments(arguments, &args, &parseArgumentsOptions{function: f}); err != nil {
+				return "", err
+			}
+			result, err := handler(ctx, args)
+			if err != nil {
+				return "", err
+			}
+			if s, ok := any(result).(string); ok {
+				return s, nil
+			}
+			b, err := json.Marshal(result)
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+	}
+	return nil
+}
+
+// tool definitions for ChatCompletionsV1Input.Tools
+func (registry *ToolRegistry) Tools() []*Tool {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	ret := make([]*Tool, 0, len(registry.names))
+	for _, name := range registry.names {
+		ret = append(ret, &Tool{
+			Type:     "function",
+			Function: registry.tools[name].function,
+		})
+	}
+	return ret
+}
+
+// execute a tool call and return the raw result.
+// A panic of the handler is returned as an error wrapping ErrToolPanicked.
+func (registry *ToolRegistry) Call(ctx context.Context, toolCall ChatCompletionsV1OutputToolCall) (ret string, err error) {
+	if toolCall.Function == nil {
+		return "", xerrors.Errorf("tool call: %s has no function: %w", toolCall.ID, ErrToolNotFound)
+	}
+	registry.mu.RLock()
+	tool, ok := registry.tools[toolCall.Function.Name]
+	registry.mu.RUnlock()
+	if !ok {
+		return "", xerrors.Errorf("tool: %s: %w", toolCall.Function.Name, ErrToolNotFound)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ret, err = "", xerrors.Errorf("tool: %s, panic: %v: %w", toolCall.Function.Name, r, ErrToolPanicked)
+		}
+	}()
+	return tool.call(ctx, toolCall.Function.Arguments)
+}
+
+// execute tool calls concurrently and return tool messages in the same order as toolCalls.
+// If a handler fails, the error is sent back to the model as the content of the tool message.
+func (registry *ToolRegistry) CallAll(ctx context.Context, toolCalls []ChatCompletionsV1OutputToolCall) []Message {
+	ret := make([]Message, len(toolCalls))
+	wg := new(sync.WaitGroup)
+	for i, tc := range toolCalls {
+		wg.Add(1)
+		go func(i int, tc ChatCompletionsV1OutputToolCall) {
+			defer wg.Done()
+			content, err := registry.Call(ctx, tc)
+			if err != nil {
+				b, _ := json.Marshal(map[string]string{"error": err.Error()})
+				content = string(b)
+			}
+			ret[i] = NewToolMessage(tc.ID, content)
+		}(i, tc)
+	}
+	wg.Wait()
+	return ret
+}
+
+// runs the chat completions and tool calling loop until the model returns a final answer
+type ToolRunner struct {
+	API           OpenAIAPIIface
+	Registry      *ToolRegistry
+	MaxIterations int // default: 10
+}
+
+func NewToolRunner(api OpenAIAPIIface, registry *ToolRegistry) *ToolRunner {
+	return &ToolRunner{
+		API:      api,
+		Registry: registry,
+	}
+}
+
+func (runner *ToolRunner) maxIterations() int {
+	if runner.MaxIterations <= 0 {
+		return 10
+	}
+	return runner.MaxIterations
+}
+
+// Run calls ChatCompletionsV1 with the registered tools, executes the returned tool calls,
+// feeds the results back and repeats until the first choice has no tool calls.
+// It returns the last output and the whole conversation including the final answer,
+// also when it fails with ErrToolMaxIterations.
+func (runner *ToolRunner) Run(ctx context.Context, input *ChatCompletionsV1Input) (*ChatCompletionsV1Output, []Message, error) {
+	req := *input
+	req.Messages = append([]Message{}, input.Messages...)
+	req.Tools = append(append([]*Tool{}, input.Tools...), runner.Registry.Tools()...)
+
+	var output *ChatCompletionsV1Output
+	for i := 0; i < runner.maxIterations(); i++ {
+		if err := ctx.Err(); err != nil {
+			return output, req.Messages, err
+		}
+		var err error
+		output, err = runner.API.ChatCompletionsV1WithContext(ctx, &req)
+		if err != nil {
+			return output, req.Messages, err
+		}
+		if len(output.Choices) == 0 {
+			return output, req.Messages, xerrors.New("choices is empty")
+		}
+		msg := output.Choices[0].Message
+		req.Messages = append(req.Messages, msg.ToMessage())
+		if len(msg.ToolCalls) == 0 {
+			return output, req.Messages, nil
+		}
+		req.Messages = append(req.Messages, runner.Registry.CallAll(ctx, msg.ToolCalls)...)
+	}
+	// the last output is returned so that its usage and tool calls are not lost
+	return output, req.Messages, xerrors.Errorf("max iterations: %d: %w", runner.maxIterations(), ErrToolMaxIterations)
+}
@@ -0,0 +1,84 @@
+package api
+
+type ChatCompletionsV1OutputToolCallRef struct {
+	ChoiceIndex int
+	ID          string // empty for the deprecated function_call
+	Name        string
+	Arguments   string
+}
+
+// iterate over every tool call in every choice in order.
+// The deprecated function_call is also yielded with an empty ID.
+// The signature is compatible with iter.Seq, so it can be used with range over func.
+// example:
+//
+//	output.AllToolCalls()(func(tc api.ChatCompletionsV1OutputToolCallRef) bool {
+//	    fmt.Println(tc.ChoiceIndex, tc.ID, tc.Name, tc.Arguments)
+//	    return true
+//	})
+func (impl *ChatCompletionsV1Output) AllToolCalls() func(yield func(ChatCompletionsV1OutputToolCallRef) bool) {
+	return func(yield func(ChatCompletionsV1OutputToolCallRef) bool) {
+		for _, c := range impl.Choices {
+			if fc := c.Message.FunctionCall; fc != nil {
+				if !yield(ChatCompletionsV1OutputToolCallRef{
+					ChoiceIndex: c.Index,
+					Name:        fc.Name,
+					Arguments:   fc.Arguments,
+				}) {
+					return
+				}
+			}
+			for _, tc := range c.Message.ToolCalls {
+				if tc.Function == nil {
+					continue
+				}
+				if !yield(ChatCompletionsV1OutputToolCallRef{
+					ChoiceIndex: c.Index,
+					ID:          tc.ID,
+					Name:        tc.Function.Name,
+					Arguments:   tc.Function.Arguments,
+				}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// list every tool call in every choice
+func (impl *ChatCompletionsV1Output) ToolCalls() []ChatCompletionsV1OutputToolCallRef {
+	ret := []ChatCompletionsV1OutputToolCallRef{}
+	impl.AllToolCalls()(func(tc ChatCompletionsV1OutputToolCallRef) bool {
+		ret = append(ret, tc)
+		return true
+	})
+	return ret
+}
+
+// list every tool call to funcName in every choice
+func (impl *ChatCompletionsV1Output) ToolCallsByName(funcName string) []ChatCompletionsV1OutputToolCallRef {
+	ret := []ChatCompletionsV1OutputToolCallRef{}
+	impl.AllToolCalls()(func(tc ChatCompletionsV1OutputToolCallRef) bool {
+		if tc.Name == funcName {
+			ret = append(ret, tc)
+		}
+		return true
+	})
+	return ret
+}
+
+// decode the arguments of every call to funcName.
+// The result is in the same order as ToolCallsByName.
+func DecodeToolCalls[T any](output *ChatCompletionsV1Output, funcName string, opts ...ParseArgumentsOption) ([]T, error) {
+	o := newParseArgumentsOptions(opts)
+	calls := output.ToolCallsByName(funcName)
+	ret := make([]T, 0, len(calls))
+	for _, tc := range calls {
+		var v T
+		if err := decodeArguments(tc.Arguments, &v, o); err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
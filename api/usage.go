@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// names of the endpoints, the same as the methods
+const (
+	EndpointListModelsV1            = "ListModelsV1"
+	EndpointRetrieveModelV1         = "RetrieveModelV1"
+	EndpointDeleteModelV1           = "DeleteModelV1"
+	EndpointChatCompletionsV1       = "ChatCompletionsV1"
+	EndpointChatCompletionsV1Stream = "ChatCompletionsV1Stream"
+	EndpointCompletionsV1           = "CompletionsV1"
+	EndpointCompletionsV1Stream     = "CompletionsV1Stream"
+	EndpointAudioTranscriptionsV1   = "AudioTranscriptionsV1"
+	EndpointListFileV1              = "ListFileV1"
+	EndpointImagesGenerationsV1     = "ImagesGenerationsV1"
+)
+
+// usage of a successful api call
+type UsageRecord struct {
+	Endpoint     string
+	Model        string
+	User         string                        // the user field of the input
+	Tag          string                        // set by WithUsageTag
+	Usage        *ChatCompletionsV1OutputUsage // tokens of chat completions and completions
+	AudioSeconds float64                       // duration of the transcribed audio, requires the verbose_json response format
+	Images       int                           // number of the generated images
+	ImageSize    string
+	ImageQuality string // empty for the default quality
+	Unmetered    bool   // the usage could not be measured, such as transcriptions without the verbose_json response format
+	Estimated    bool   // the usage is estimated, such as a stream which ended before the usage chunk
+	Time         time.Time
+}
+
+// receives the usage of every successful api call.
+// When a recorder is set, the streams without stream options are sent with stream_options.include_usage,
+// so they end with an extra chunk which has the usage and no choices.
+// A stream which ends before the usage chunk, or which sets include_usage to false, is recorded with an estimate.
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, record *UsageRecord)
+}
+
+type UsageRecorderFunc func(ctx context.Context, record *UsageRecord)
+
+func (f UsageRecorderFunc) RecordUsage(ctx context.Context, record *UsageRecord) {
+	f(ctx, record)
+}
+
+// add a usage recorder, it can be used several times
+func WithUsageRecorder(r UsageRecorder) Option {
+	return func(api *OpenAIAPI) {
+		api.usageRecorders = append(api.usageRecorders, r)
+	}
+}
+
+// checks the estimated usage of a call before it is sent, for example against a budget.
+// The estimate of chat completions and completions counts max_tokens as the output tokens,
+// or the allowance set by WithOutputTokenAllowance when max_tokens is not set,
+// and the estimate of transcriptions has no audio duration.
+type UsageGuard interface {
+	CheckUsage(ctx context.Context, estimate *UsageRecord) error
+}
+
+type UsageGuardFunc func(ctx context.Context, estimate *UsageRecord) error
+
+func (f UsageGuardFunc) CheckUsage(ctx context.Context, estimate *UsageRecord) error {
+	return f(ctx, estimate)
+}
+
+// add a usage guard, it can be used several times
+func WithUsageGuard(g UsageGuard) Option {
+	return func(api *OpenAIAPI) {
+		api.usageGuards = append(api.usageGuards, g)
+	}
+}
+
+// output tokens of each choice counted by the usage estimate of the calls without max_tokens
+const DefaultOutputTokenAllowance = 1024
+
+// use MaxOutputTokens of the model as the output token allowance
+const MaxOutputTokensAllowance = -1
+
+// output tokens of each choice counted by the usage estimate of the calls without max_tokens.
+// default: DefaultOutputTokenAllowance. With MaxOutputTokensAllowance, the estimate never
+// undercounts but a budget rejects the calls to the models with a large cap, such as gpt-5, sooner.
+func WithOutputTokenAllowance(n int) Option {
+	return func(api *OpenAIAPI) {
+		api.outputTokenAllowance = n
+	}
+}
+
+type usageTagKey struct{}
+
+// attach a caller-defined tag to the usage of the calls with ctx
+func WithUsageTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, usageTagKey{}, tag)
+}
+
+func UsageTag(ctx context.Context) string {
+	tag, _ := ctx.Value(usageTagKey{}).(string)
+	return tag
+}
+
+func (api *OpenAIAPI) recordUsage(ctx context.Context, record *UsageRecord) {
+	if len(api.usageRecorders) == 0 {
+		return
+	}
+	record.Tag = UsageTag(ctx)
+	record.Time = time.Now()
+	for _, r := range api.usageRecorders {
+		r.RecordUsage(ctx, record)
+	}
+}
+
+func (api *OpenAIAPI) checkUsage(ctx context.Context, estimate func() *UsageRecord) error {
+	if len(api.usageGuards) == 0 {
+		return nil
+	}
+	record := estimate()
+	record.Tag = UsageTag(ctx)
+	record.Time = time.Now()
+	for _, g := range api.usageGuards {
+		if err := g.CheckUsage(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func estimatedTokenUsage(prompt, completion int) *ChatCompletionsV1OutputUsage {
+	return &ChatCompletionsV1OutputUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+}
+
+// output token caps of the models, used to estimate the usage with MaxOutputTokensAllowance.
+// Looked up with LookupModel, fine-tuned models use the cap of the base model.
+var MaxOutputTokens = map[string]int{
+	"gpt-5":                  128000,
+	"gpt-5-mini":             128000,
+	"gpt-5-nano":             128000,
+	"gpt-4.1":                32768,
+	"gpt-4.1-mini":           32768,
+	"gpt-4.1-nano":           32768,
+	"gpt-4o":                 16384,
+	"gpt-4o-2024-05-13":      4096,
+	"gpt-4o-mini":            16384,
+	"gpt-4o-audio-preview":   16384,
+	"chatgpt-4o-latest":      16384,
+	"gpt-4-turbo":            4096,
+	"gpt-4-turbo-preview":    4096,
+	"gpt-4":                  8192,
+	"gpt-4-32k":              32768,
+	"gpt-3.5-turbo":          4096,
+	"gpt-3.5-turbo-instruct": 4096,
+	"o1":                     100000,
+	"o1-preview":             32768,
+	"o1-mini":                65536,
+	"o1-pro":                 100000,
+	"o3":                     100000,
+	"o3-pro":                 100000,
+	"o3-mini":                100000,
+	"o4-mini":                100000,
+}
+
+// used for the models which are not in MaxOutputTokens
+const DefaultMaxOutputTokens = 4096
+
+// output token cap of a model, fine-tuned models use the cap of the base model
+func maxOutputTokens(model string) int {
+	if base, ok := FineTunedBaseModel(model); ok {
+		model = base
+	}
+	if n, ok := LookupModel(MaxOutputTokens, model); ok {
+		return n
+	}
+	return DefaultMaxOutputTokens
+}
+
+// output tokens of each choice of a call without max_tokens
+func (api *OpenAIAPI) outputTokens(model string) int {
+	if api.outputTokenAllowance == MaxOutputTokensAllowance {
+		return maxOutputTokens(model)
+	}
+	return max(api.outputTokenAllowance, 0)
+}
+
+// usage estimate before the call
+func (api *OpenAIAPI) chatUsageEstimate(input *ChatCompletionsV1Input) *ChatCompletionsV1OutputUsage {
+	output := chatMaxTokens(input)
+	if output == 0 {
+		output = api.outputTokens(*input.Model) * max(input.N, 1)
+	}
+	return estimatedTokenUsage(api.limiter.chatPromptTokens(input), output)
+}
+
+// usage estimate before the call
+func (api *OpenAIAPI) completionsUsageEstimate(input *CompletionsV1Input) *ChatCompletionsV1OutputUsage {
+	output := completionsMaxTokens(input)
+	if output == 0 {
+		output = api.outputTokens(*input.Model) * max(lo.FromPtr(input.N), 1)
+	}
+	return estimatedTokenUsage(api.limiter.completionsPromptTokens(input), output)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -13,8 +14,39 @@ import (
 )
 
 type Message struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role       string                            `json:"role,omitempty"`
+	Content    string                            `json:"content,omitempty"`
+	Name       string                            `json:"name,omitempty"`
+	ToolCalls  []ChatCompletionsV1OutputToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string                            `json:"tool_call_id,omitempty"`
+	Refusal    string                            `json:"refusal,omitempty"`
+	Audio      *MessageAudio                     `json:"audio,omitempty"`
+}
+
+// the content of tool messages is always sent, the api rejects tool messages without it
+func (m Message) MarshalJSON() ([]byte, error) {
+	type alias Message
+	if m.Role != "tool" {
+		return json.Marshal(alias(m))
+	}
+	return json.Marshal(struct {
+		alias
+		Content string `json:"content"`
+	}{alias(m), m.Content})
+}
+
+// reference to a previous audio response from the model
+type MessageAudio struct {
+	ID string `json:"id"`
+}
+
+// generate a tool role message that answers the tool call identified by toolCallID
+func NewToolMessage(toolCallID, content string) Message {
+	return Message{
+		Role:       "tool",
+		Content:    content,
+		ToolCallID: toolCallID,
+	}
 }
 
 type Tool struct {
@@ -24,21 +56,78 @@ type Tool struct {
 
 // doc: https://platform.openai.com/docs/api-reference/chat
 type ChatCompletionsV1Input struct {
-	Model            *string     `json:"model,omitempty"`
-	Messages         []Message   `json:"messages,omitempty"`
-	Functions        []*Function `json:"functions,omitempty"` // Deprecated
-	Temperature      *float32    `json:"temperature,omitempty"`
-	TopP             *float32    `json:"top_p,omitempty"`
-	N                int         `json:"n,omitempty"`
-	Stop             []string    `json:"stop,omitempty"`
-	MaxTokens        *int        `json:"max_tokens,omitempty"`
-	PresencePenalty  *float32    `json:"presence_penalty,omitempty"`
-	FrequencyPenalty *float32    `json:"frequency_penalty,omitempty"`
-	LogitBias        any         `json:"logit_bias,omitempty"`
-	User             *string     `json:"user,omitempty"`
-	FunctionCall     any         `json:"function_call,omitempty"` // Deprecated
-	Tools            []*Tool     `json:"tools,omitempty"`
-	ToolChoice       any         `json:"tool_choice,omitempty"`
+	Model            *string         `json:"model,omitempty"`
+	Messages         []Message       `json:"messages,omitempty"`
+	Functions        []*Function     `json:"functions,omitempty"` // Deprecated
+	Temperature      *float32        `json:"temperature,omitempty"`
+	TopP             *float32        `json:"top_p,omitempty"`
+	N                int             `json:"n,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	PresencePenalty  *float32        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float32        `json:"frequency_penalty,omitempty"`
+	LogitBias        any             `json:"logit_bias,omitempty"`
+	User             *string         `json:"user,omitempty"`
+	FunctionCall     any             `json:"function_call,omitempty"` // Deprecated
+	Tools            []*Tool         `json:"tools,omitempty"`
+	ToolChoice       any             `json:"tool_choice,omitempty"`
+	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
+
+	Seed                *int                                    `json:"seed,omitempty"`
+	Logprobs            *bool                                   `json:"logprobs,omitempty"`
+	TopLogprobs         *int                                    `json:"top_logprobs,omitempty"`
+	ParallelToolCalls   *bool                                   `json:"parallel_tool_calls,omitempty"`
+	MaxCompletionTokens *int                                    `json:"max_completion_tokens,omitempty"`
+	ReasoningEffort     *string                                 `json:"reasoning_effort,omitempty"` // minimal, low, medium, high
+	ServiceTier         *string                                 `json:"service_tier,omitempty"`     // auto, default, flex, priority
+	Store               *bool                                   `json:"store,omitempty"`
+	Metadata            map[string]string                       `json:"metadata,omitempty"`
+	Modalities          []string                                `json:"modalities,omitempty"` // text, audio
+	Audio               *ChatCompletionsV1InputAudio            `json:"audio,omitempty"`
+	Prediction          *ChatCompletionsV1InputPrediction       `json:"prediction,omitempty"`
+	StreamOptions       *ChatCompletionsV1InputStreamOptions    `json:"stream_options,omitempty"`
+	WebSearchOptions    *ChatCompletionsV1InputWebSearchOptions `json:"web_search_options,omitempty"`
+
+	ExtraBody map[string]any `json:"-"` // merged into the request body, for vendor-specific parameters
+
+	stream bool // set by ChatCompletionsV1Stream
+}
+
+func (input *ChatCompletionsV1Input) MarshalJSON() ([]byte, error) {
+	type alias ChatCompletionsV1Input
+	return marshalWithExtraBody((*alias)(input), withStream(input.ExtraBody, input.stream))
+}
+
+type ChatCompletionsV1InputAudio struct {
+	Voice  string `json:"voice"`  // alloy, ash, ballad, coral, echo, sage, shimmer, verse
+	Format string `json:"format"` // wav, mp3, flac, opus, pcm16
+}
+
+// predicted output to speed up the response
+type ChatCompletionsV1InputPrediction struct {
+	Type    string `json:"type"`    // content
+	Content any    `json:"content"` // string or array of content parts
+}
+
+type ChatCompletionsV1InputStreamOptions struct {
+	IncludeUsage *bool `json:"include_usage,omitempty"`
+}
+
+type ChatCompletionsV1InputWebSearchUserLocationApproximate struct {
+	City     *string `json:"city,omitempty"`
+	Country  *string `json:"country,omitempty"` // ISO 3166-1 two-letter country code
+	Region   *string `json:"region,omitempty"`
+	Timezone *string `json:"timezone,omitempty"` // IANA timezone
+}
+
+type ChatCompletionsV1InputWebSearchUserLocation struct {
+	Type        string                                                  `json:"type"` // approximate
+	Approximate *ChatCompletionsV1InputWebSearchUserLocationApproximate `json:"approximate,omitempty"`
+}
+
+type ChatCompletionsV1InputWebSearchOptions struct {
+	SearchContextSize *string                                      `json:"search_context_size,omitempty"` // low, medium, high
+	UserLocation      *ChatCompletionsV1InputWebSearchUserLocation `json:"user_location,omitempty"`
 }
 
 func (input *ChatCompletionsV1Input) Validate() error {
@@ -51,10 +140,24 @@ func (input *ChatCompletionsV1Input) Validate() error {
 	return nil
 }
 
+type ChatCompletionsV1OutputUsageCompletionTokensDetails struct {
+	ReasoningTokens          int `json:"reasoning_tokens,omitempty"`
+	AudioTokens              int `json:"audio_tokens,omitempty"`
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens,omitempty"`
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens,omitempty"`
+}
+
+type ChatCompletionsV1OutputUsagePromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens,omitempty"`
+	AudioTokens  int `json:"audio_tokens,omitempty"`
+}
+
 type ChatCompletionsV1OutputUsage struct {
-	PromptTokens     int `json:"prompt_tokens,omitempty"`
-	CompletionTokens int `json:"completion_tokens,omitempty"`
-	TotalTokens      int `json:"total_tokens,omitempty"`
+	PromptTokens            int                                                  `json:"prompt_tokens,omitempty"`
+	CompletionTokens        int                                                  `json:"completion_tokens,omitempty"`
+	TotalTokens             int                                                  `json:"total_tokens,omitempty"`
+	CompletionTokensDetails *ChatCompletionsV1OutputUsageCompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+	PromptTokensDetails     *ChatCompletionsV1OutputUsagePromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
 }
 
 type ChatCompletionsV1OutputChoiceFunctionCall struct {
@@ -67,12 +170,85 @@ type ChatCompletionsV1OutputChoiceMessage struct {
 	Content      *string                                    `json:"content,omitempty"`
 	FunctionCall *ChatCompletionsV1OutputChoiceFunctionCall `json:"function_call,omitempty"`
 	ToolCalls    []ChatCompletionsV1OutputToolCall          `json:"tool_calls,omitempty"`
+	Refusal      *string                                    `json:"refusal,omitempty"`
+	Annotations  []ChatCompletionsV1OutputAnnotation        `json:"annotations,omitempty"`
+	Audio        *ChatCompletionsV1OutputAudio              `json:"audio,omitempty"`
+	ExtraFields  map[string]json.RawMessage                 `json:"-"` // fields which are not declared in this struct, for example reasoning_content
+}
+
+func (impl *ChatCompletionsV1OutputChoiceMessage) UnmarshalJSON(data []byte) error {
+	type alias ChatCompletionsV1OutputChoiceMessage
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.ExtraFields = extra
+	return nil
+}
+
+type ChatCompletionsV1OutputAnnotationURLCitation struct {
+	StartIndex int    `json:"start_index,omitempty"`
+	EndIndex   int    `json:"end_index,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
+
+type ChatCompletionsV1OutputAnnotation struct {
+	Type        string                                        `json:"type,omitempty"` // url_citation
+	URLCitation *ChatCompletionsV1OutputAnnotationURLCitation `json:"url_citation,omitempty"`
+}
+
+type ChatCompletionsV1OutputAudio struct {
+	ID         string `json:"id,omitempty"`
+	ExpiresAt  int    `json:"expires_at,omitempty"`
+	Data       string `json:"data,omitempty"` // base64 encoded audio
+	Transcript string `json:"transcript,omitempty"`
+}
+
+type ChatCompletionsV1OutputTopLogprob struct {
+	Token   string  `json:"token,omitempty"`
+	Logprob float64 `json:"logprob,omitempty"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
+
+type ChatCompletionsV1OutputTokenLogprob struct {
+	Token       string                              `json:"token,omitempty"`
+	Logprob     float64                             `json:"logprob,omitempty"`
+	Bytes       []int                               `json:"bytes,omitempty"`
+	TopLogprobs []ChatCompletionsV1OutputTopLogprob `json:"top_logprobs,omitempty"`
+}
+
+type ChatCompletionsV1OutputLogprobs struct {
+	Content []ChatCompletionsV1OutputTokenLogprob `json:"content,omitempty"`
+	Refusal []ChatCompletionsV1OutputTokenLogprob `json:"refusal,omitempty"`
+}
+
+// convert the returned message into an assistant message for the next request
+func (impl *ChatCompletionsV1OutputChoiceMessage) ToMessage() Message {
+	ret := Message{
+		Role:      impl.Role,
+		ToolCalls: impl.ToolCalls,
+	}
+	if ret.Role == "" {
+		ret.Role = "assistant"
+	}
+	if impl.Content != nil {
+		ret.Content = *impl.Content
+	}
+	if impl.Refusal != nil {
+		ret.Refusal = *impl.Refusal
+	}
+	if impl.Audio != nil && impl.Audio.ID != "" {
+		ret.Audio = &MessageAudio{ID: impl.Audio.ID}
+	}
+	return ret
 }
 
 type ChatCompletionsV1OutputChoice struct {
 	Message      ChatCompletionsV1OutputChoiceMessage `json:"message,omitempty"`
 	FinishReason string                               `json:"finish_reason,omitempty"`
 	Index        int                                  `json:"index,omitempty"`
+	Logprobs     *ChatCompletionsV1OutputLogprobs     `json:"logprobs,omitempty"`
 }
 
 type ChatCompletionsV1OutputToolCallFunction struct {
@@ -87,67 +263,125 @@ type ChatCompletionsV1OutputToolCall struct {
 }
 
 type ChatCompletionsV1Output struct {
-	ID      *string                         `json:"id,omitempty"`
-	Object  *string                         `json:"object,omitempty"`
-	Created *int                            `json:"created,omitempty"`
-	Model   *string                         `json:"model,omitempty"`
-	Usage   *ChatCompletionsV1OutputUsage   `json:"usage,omitempty"`
-	Choices []ChatCompletionsV1OutputChoice `json:"choices,omitempty"`
-	Error   *Error                          `json:"error,omitempty"`
+	ID                *string                         `json:"id,omitempty"`
+	Object            *string                         `json:"object,omitempty"`
+	Created           *int                            `json:"created,omitempty"`
+	Model             *string                         `json:"model,omitempty"`
+	Usage             *ChatCompletionsV1OutputUsage   `json:"usage,omitempty"`
+	Choices           []ChatCompletionsV1OutputChoice `json:"choices,omitempty"`
+	SystemFingerprint *string                         `json:"system_fingerprint,omitempty"`
+	ServiceTier       *string                         `json:"service_tier,omitempty"`
+	Error             *Error                          `json:"error,omitempty"`
+	Raw               json.RawMessage                 `json:"-"` // raw response body
+	ExtraFields       map[string]json.RawMessage      `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata  *ResponseMetadata               `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *ChatCompletionsV1Output) UnmarshalJSON(data []byte) error {
+	type alias ChatCompletionsV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
+}
+
+type parseArgumentsOptions struct {
+	function *Function
+	repair   bool
+	onRepair func(repairs []JSONRepair)
+}
+
+type ParseArgumentsOption func(*parseArgumentsOptions)
+
+// validate the arguments against the parameters schema of f before decoding.
+// If the arguments are invalid, *ArgumentsValidationError is returned.
+func WithArgumentsValidation(f *Function) ParseArgumentsOption {
+	return func(o *parseArgumentsOptions) {
+		o.function = f
+	}
 }
 
-func (impl *ChatCompletionsV1Output) parseArgumentsFromFunctionCalls(funcName string, functionCalls []*ChatCompletionsV1OutputChoiceFunctionCall, v any) error {
+// repair malformed arguments with RepairJSON before validating and decoding.
+// onRepair is called with the applied repairs when the arguments are repaired. It can be nil.
+func WithJSONRepair(onRepair func(repairs []JSONRepair)) ParseArgumentsOption {
+	return func(o *parseArgumentsOptions) {
+		o.repair = true
+		o.onRepair = onRepair
+	}
+}
+
+func newParseArgumentsOptions(opts []ParseArgumentsOption) *parseArgumentsOptions {
+	ret := &parseArgumentsOptions{}
+	for _, opt := range opts {
+		opt(ret)
+	}
+	return ret
+}
+
+func decodeArguments(arguments string, v any, opts *parseArgumentsOptions) error {
+	if opts.repair {
+		repaired, repairs, err := RepairJSON(arguments)
+		if err != nil {
+			return err
+		}
+		if len(repairs) != 0 && opts.onRepair != nil {
+			opts.onRepair(repairs)
+		}
+		arguments = repaired
+	}
+	if opts.function != nil {
+		if err := opts.function.ValidateArguments(arguments); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal([]byte(arguments), v); err != nil {
+		return xerrors.Errorf("%v: %w", err, ErrParseFunctionCallingArguments)
+	}
+	return nil
+}
+
+func (impl *ChatCompletionsV1Output) parseArgumentsFromFunctionCalls(funcName string, functionCalls []*ChatCompletionsV1OutputChoiceFunctionCall, v any, opts *parseArgumentsOptions) error {
 	for _, fc := range functionCalls {
 		if fc.Name != funcName {
 			continue
 		}
-		if err := json.Unmarshal([]byte(fc.Arguments), v); err != nil {
-			return err
-		}
-		return nil
+		return decodeArguments(fc.Arguments, v, opts)
 	}
 	return xerrors.Errorf("function name: %s is not found: %w", funcName, ErrParseFunctionCallingArguments)
 }
 
-func (impl *ChatCompletionsV1Output) parseArgumentsFromToolCalls(funcName string, toolCalls []ChatCompletionsV1OutputToolCall, v any) error {
+func (impl *ChatCompletionsV1Output) parseArgumentsFromToolCalls(funcName string, toolCalls []ChatCompletionsV1OutputToolCall, v any, opts *parseArgumentsOptions) error {
 	for _, tc := range toolCalls {
-		if tc.Function.Name != funcName {
+		if tc.Function == nil || tc.Function.Name != funcName {
 			continue
 		}
-		if err := json.Unmarshal([]byte(tc.Function.Arguments), v); err != nil {
-			return err
-		}
-		return nil
+		return decodeArguments(tc.Function.Arguments, v, opts)
 	}
 	return xerrors.Errorf("function name: %s is not found: %w", funcName, ErrParseFunctionCallingArguments)
 }
 
 // parse function calling arguments
-func (impl *ChatCompletionsV1Output) ParseArguments(funcName string, v any) error {
+func (impl *ChatCompletionsV1Output) ParseArguments(funcName string, v any, opts ...ParseArgumentsOption) error {
 	if impl.Choices == nil {
 		return xerrors.Errorf("choices is nil: %w", ErrParseFunctionCallingArguments)
 	}
+	o := newParseArgumentsOptions(opts)
 	functionCalls := lo.Filter(lo.Map(impl.Choices, func(c ChatCompletionsV1OutputChoice, _ int) *ChatCompletionsV1OutputChoiceFunctionCall {
 		return c.Message.FunctionCall
 	}), func(fc *ChatCompletionsV1OutputChoiceFunctionCall, _ int) bool {
 		return fc != nil
 	})
 	if len(functionCalls) != 0 {
-		return impl.parseArgumentsFromFunctionCalls(funcName, functionCalls, v)
+		return impl.parseArgumentsFromFunctionCalls(funcName, functionCalls, v, o)
 	}
 
-	toolCalls := lo.Filter(lo.Map(impl.Choices, func(c ChatCompletionsV1OutputChoice, _ int) []ChatCompletionsV1OutputToolCall {
+	toolCalls := lo.FlatMap(impl.Choices, func(c ChatCompletionsV1OutputChoice, _ int) []ChatCompletionsV1OutputToolCall {
 		return c.Message.ToolCalls
-	}), func(tc []ChatCompletionsV1OutputToolCall, _ int) bool {
-		return len(tc) != 0
 	})
-
-	for _, tc := range toolCalls {
-		if err := impl.parseArgumentsFromToolCalls(funcName, tc, v); err == nil {
-			return nil
-		}
-	}
-	return xerrors.Errorf("function name: %s is not found: %w", funcName, ErrParseFunctionCallingArguments)
+	return impl.parseArgumentsFromToolCalls(funcName, toolCalls, v, o)
 }
 
 func (impl *ChatCompletionsV1Output) String() string {
@@ -157,30 +391,59 @@ func (impl *ChatCompletionsV1Output) String() string {
 }
 
 func (api *OpenAIAPI) ChatCompletionsV1(input *ChatCompletionsV1Input) (*ChatCompletionsV1Output, error) {
+	return api.ChatCompletionsV1WithContext(context.Background(), input)
+}
+
+func (api *OpenAIAPI) ChatCompletionsV1WithContext(ctx context.Context, input *ChatCompletionsV1Input) (*ChatCompletionsV1Output, error) {
+	return intercept(api, ctx, EndpointChatCompletionsV1, input, api.chatCompletionsV1)
+}
+
+func (api *OpenAIAPI) chatCompletionsV1(ctx context.Context, input *ChatCompletionsV1Input) (*ChatCompletionsV1Output, error) {
 	if err := input.Validate(); err != nil {
 		return nil, err
 	}
+	if err := api.checkUsage(ctx, func() *UsageRecord {
+		return &UsageRecord{
+			Endpoint: EndpointChatCompletionsV1,
+			Model:    *input.Model,
+			User:     lo.FromPtr(input.User),
+			Usage:    api.chatUsageEstimate(input),
+		}
+	}); err != nil {
+		return nil, err
+	}
+	estimated := api.limiter.estimateChat(input)
+	if err := api.limiter.wait(ctx, *input.Model, estimated); err != nil {
+		return nil, err
+	}
+	// refunded unless the call succeeds
+	used := 0
+	defer func() {
+		api.limiter.reconcile(*input.Model, estimated, used)
+	}()
 	reqBody := new(bytes.Buffer)
 	if err := json.NewEncoder(reqBody).Encode(input); err != nil {
 		return nil, err
 	}
 
-	endpoint, err := api.endpoint()
+	endpoint, err := api.requestURL("/v1/chat/completions", input.Model)
 	if err != nil {
 		return nil, err
 	}
-	endpoint.Path = "/v1/chat/completions"
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
 		endpoint.String(),
 		reqBody,
 	)
-	req.Header.Add("Content-Type", "application/json")
-	api.setToken(req)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := api.httpClient.Do(req)
+	req.Header.Add("Content-Type", "application/json")
+	if err := api.setToken(req); err != nil {
+		return nil, err
+	}
+	resp, md, err := api.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -188,21 +451,32 @@ func (api *OpenAIAPI) ChatCompletionsV1(input *ChatCompletionsV1Input) (*ChatCom
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		ret := &ChatCompletionsV1Output{}
+		used = estimated
+		ret := &ChatCompletionsV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
+		}
+		if ret.Usage != nil {
+			used = ret.Usage.TotalTokens
 		}
+		api.recordUsage(ctx, &UsageRecord{
+			Endpoint: EndpointChatCompletionsV1,
+			Model:    *input.Model,
+			User:     lo.FromPtr(input.User),
+			Usage:    ret.Usage,
+		})
 		return ret, nil
 	case http.StatusUnauthorized:
-		ret := &ChatCompletionsV1Output{}
+		ret := &ChatCompletionsV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
 		return ret, ErrUnauthorized
 	case http.StatusBadGateway:
 		buf := new(bytes.Buffer)
 		io.Copy(buf, resp.Body)
 		ret := &ChatCompletionsV1Output{
+			ResponseMetadata: md,
 			Error: &Error{
 				Message: buf.String(),
 			},
@@ -212,6 +486,7 @@ func (api *OpenAIAPI) ChatCompletionsV1(input *ChatCompletionsV1Input) (*ChatCom
 		buf := new(bytes.Buffer)
 		io.Copy(buf, resp.Body)
 		ret := &ChatCompletionsV1Output{
+			ResponseMetadata: md,
 			Error: &Error{
 				Message: buf.String(),
 			},
@@ -245,8 +520,24 @@ func NewFunction(funcName, description string, v any) (*Function, error) {
 
 }
 
+// generate function_calling function for the strict mode.
+// The parameters schema is generated by GenerateStrictSchema and strict is set to true.
+func NewStrictFunction(funcName, description string, v any) (*Function, error) {
+	parameters, err := GenerateStrictSchema(reflect.TypeOf(v))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to generate schema: %w", err)
+	}
+	return &Function{
+		Name:        funcName,
+		Description: description,
+		Paramaters:  parameters,
+		Strict:      lo.ToPtr(true),
+	}, nil
+}
+
 type Function struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
 	Paramaters  *schema.Schema `json:"parameters"`
+	Strict      *bool          `json:"strict,omitempty"`
 }
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+)
+
+type ChatCompletionsV1StreamChunkToolCall struct {
+	Index    int                                      `json:"index"`
+	ID       string                                   `json:"id,omitempty"`
+	Type     string                                   `json:"type,omitempty"`
+	Function *ChatCompletionsV1OutputToolCallFunction `json:"function,omitempty"`
+}
+
+type ChatCompletionsV1StreamChunkDelta struct {
+	Role         string                                     `json:"role,omitempty"`
+	Content      *string                                    `json:"content,omitempty"`
+	Refusal      *string                                    `json:"refusal,omitempty"`
+	FunctionCall *ChatCompletionsV1OutputChoiceFunctionCall `json:"function_call,omitempty"`
+	ToolCalls    []ChatCompletionsV1StreamChunkToolCall     `json:"tool_calls,omitempty"`
+}
+
+type ChatCompletionsV1StreamChunkChoice struct {
+	Index        int                               `json:"index"`
+	Delta        ChatCompletionsV1StreamChunkDelta `json:"delta,omitempty"`
+	FinishReason *string                           `json:"finish_reason,omitempty"`
+	Logprobs     *ChatCompletionsV1OutputLogprobs  `json:"logprobs,omitempty"`
+}
+
+// doc: https://platform.openai.com/docs/api-reference/chat/streaming
+type ChatCompletionsV1StreamChunk struct {
+	ID                *string                              `json:"id,omitempty"`
+	Object            *string                              `json:"object,omitempty"`
+	Created           *int                                 `json:"created,omitempty"`
+	Model             *string                              `json:"model,omitempty"`
+	SystemFingerprint *string                              `json:"system_fingerprint,omitempty"`
+	ServiceTier       *string                              `json:"service_tier,omitempty"`
+	Choices           []ChatCompletionsV1StreamChunkChoice `json:"choices,omitempty"`
+	Usage             *ChatCompletionsV1OutputUsage        `json:"usage,omitempty"` // only in the last chunk with stream_options.include_usage
+}
+
+// content delta of the first choice
+func (impl *ChatCompletionsV1StreamChunk) Content() string {
+	if len(impl.Choices) == 0 || impl.Choices[0].Delta.Content == nil {
+		return ""
+	}
+	return *impl.Choices[0].Delta.Content
+}
+
+func (api *OpenAIAPI) ChatCompletionsV1Stream(ctx context.Context, input *ChatCompletionsV1Input) (*Stream[ChatCompletionsV1StreamChunk], error) {
+	return intercept(api, ctx, EndpointChatCompletionsV1Stream, input, api.chatCompletionsV1Stream)
+}
+
+func (api *OpenAIAPI) chatCompletionsV1Stream(ctx context.Context, input *ChatCompletionsV1Input) (*Stream[ChatCompletionsV1StreamChunk], error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := api.checkUsage(ctx, func() *UsageRecord {
+		return &UsageRecord{
+			Endpoint: EndpointChatCompletionsV1Stream,
+			Model:    *input.Model,
+			User:     lo.FromPtr(input.User),
+			Usage:    api.chatUsageEstimate(input),
+		}
+	}); err != nil {
+		return nil, err
+	}
+	estimated := api.limiter.estimateChat(input)
+	if err := api.limiter.wait(ctx, *input.Model, estimated); err != nil {
+		return nil, err
+	}
+	req := *input
+	req.stream = true
+	if len(api.usageRecorders) > 0 && req.StreamOptions == nil {
+		// otherwise the usage is not sent
+		req.StreamOptions = &ChatCompletionsV1InputStreamOptions{IncludeUsage: lo.ToPtr(true)}
+	}
+	body, md, err := api.openStream(ctx, "/v1/chat/completions", req.Model, &req)
+	if err != nil {
+		api.limiter.reconcile(*input.Model, estimated, 0)
+		return nil, err
+	}
+	ret := newStream[ChatCompletionsV1StreamChunk](body, md)
+	// a stream which ends before the usage chunk is reconciled and recorded with the prompt estimate
+	// and one token for each chunk with choices
+	var usage *ChatCompletionsV1OutputUsage
+	chunks := 0
+	ret.onEvent = func(chunk *ChatCompletionsV1StreamChunk) {
+		if len(chunk.Choices) > 0 {
+			chunks++
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+			api.recordUsage(ctx, &UsageRecord{
+				Endpoint: EndpointChatCompletionsV1Stream,
+				Model:    *input.Model,
+				User:     lo.FromPtr(input.User),
+				Usage:    chunk.Usage,
+			})
+		}
+	}
+	ret.onDone = func() {
+		if usage != nil {
+			api.limiter.reconcile(*input.Model, estimated, usage.TotalTokens)
+			return
+		}
+		estimate := estimatedTokenUsage(api.limiter.chatPromptTokens(input), chunks)
+		api.limiter.reconcile(*input.Model, estimated, estimate.TotalTokens)
+		api.recordUsage(ctx, &UsageRecord{
+			Endpoint:  EndpointChatCompletionsV1Stream,
+			Model:     *input.Model,
+			User:      lo.FromPtr(input.User),
+			Usage:     estimate,
+			Estimated: true,
+		})
+	}
+	return ret, nil
+}
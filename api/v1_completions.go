@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+// doc: https://platform.openai.com/docs/api-reference/completions
+type CompletionsV1Input struct {
+	Model            *string                              `json:"model,omitempty"`
+	Prompt           any                                  `json:"prompt,omitempty"` // string, []string, []int or [][]int
+	Suffix           *string                              `json:"suffix,omitempty"`
+	MaxTokens        *int                                 `json:"max_tokens,omitempty"`
+	Temperature      *float32                             `json:"temperature,omitempty"`
+	TopP             *float32                             `json:"top_p,omitempty"`
+	N                *int                                 `json:"n,omitempty"`
+	Logprobs         *int                                 `json:"logprobs,omitempty"`
+	Echo             *bool                                `json:"echo,omitempty"`
+	Stop             []string                             `json:"stop,omitempty"`
+	PresencePenalty  *float32                             `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float32                             `json:"frequency_penalty,omitempty"`
+	BestOf           *int                                 `json:"best_of,omitempty"`
+	LogitBias        any                                  `json:"logit_bias,omitempty"`
+	Seed             *int                                 `json:"seed,omitempty"`
+	User             *string                              `json:"user,omitempty"`
+	StreamOptions    *ChatCompletionsV1InputStreamOptions `json:"stream_options,omitempty"`
+
+	ExtraBody map[string]any `json:"-"` // merged into the request body, for vendor-specific parameters
+
+	stream bool // set by CompletionsV1Stream
+}
+
+func (input *CompletionsV1Input) MarshalJSON() ([]byte, error) {
+	type alias CompletionsV1Input
+	return marshalWithExtraBody((*alias)(input), withStream(input.ExtraBody, input.stream))
+}
+
+func (input *CompletionsV1Input) Validate() error {
+	if input.Model == nil {
+		return xerrors.New("model is empty")
+	}
+	if input.Prompt == nil {
+		return xerrors.New("prompt is empty")
+	}
+	return nil
+}
+
+type CompletionsV1OutputLogprobs struct {
+	Tokens        []string             `json:"tokens,omitempty"`
+	TokenLogprobs []float64            `json:"token_logprobs,omitempty"`
+	TopLogprobs   []map[string]float64 `json:"top_logprobs,omitempty"`
+	TextOffset    []int                `json:"text_offset,omitempty"`
+}
+
+type CompletionsV1OutputChoice struct {
+	Text         string                       `json:"text,omitempty"`
+	Index        int                          `json:"index,omitempty"`
+	Logprobs     *CompletionsV1OutputLogprobs `json:"logprobs,omitempty"`
+	FinishReason string                       `json:"finish_reason,omitempty"`
+}
+
+type CompletionsV1Output struct {
+	ID                *string                       `json:"id,omitempty"`
+	Object            *string                       `json:"object,omitempty"`
+	Created           *int                          `json:"created,omitempty"`
+	Model             *string                       `json:"model,omitempty"`
+	Choices           []CompletionsV1OutputChoice   `json:"choices,omitempty"`
+	Usage             *ChatCompletionsV1OutputUsage `json:"usage,omitempty"`
+	SystemFingerprint *string                       `json:"system_fingerprint,omitempty"`
+	Error             *Error                        `json:"error,omitempty"`
+
+	Raw              json.RawMessage            `json:"-"` // raw response body
+	ExtraFields      map[string]json.RawMessage `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata *ResponseMetadata          `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *CompletionsV1Output) UnmarshalJSON(data []byte) error {
+	type alias CompletionsV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
+}
+
+// text of the first choice
+func (impl *CompletionsV1Output) Text() string {
+	if len(impl.Choices) == 0 {
+		return ""
+	}
+	return impl.Choices[0].Text
+}
+
+func (impl *CompletionsV1Output) String() string {
+	buf := new(bytes.Buffer)
+	json.NewEncoder(buf).Encode(impl)
+	return buf.String()
+}
+
+func (api *OpenAIAPI) CompletionsV1(input *CompletionsV1Input) (*CompletionsV1Output, error) {
+	return api.CompletionsV1WithContext(context.Background(), input)
+}
+
+func (api *OpenAIAPI) CompletionsV1WithContext(ctx context.Context, input *CompletionsV1Input) (*CompletionsV1Output, error) {
+	return intercept(api, ctx, EndpointCompletionsV1, input, api.completionsV1)
+}
+
+func (api *OpenAIAPI) completionsV1(ctx context.Context, input *CompletionsV1Input) (*CompletionsV1Output, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := api.checkUsage(ctx, func() *UsageRecord {
+		return &UsageRecord{
+			Endpoint: EndpointCompletionsV1,
+			Model:    *input.Model,
+			User:     lo.FromPtr(input.User),
+			Usage:    api.completionsUsageEstimate(input),
+		}
+	}); err != nil {
+		return nil, err
+	}
+	estimated := api.limiter.estimateCompletions(input)
+	if err := api.limiter.wait(ctx, *input.Model, estimated); err != nil {
+		return nil, err
+	}
+	// refunded unless the call succeeds
+	used := 0
+	defer func() {
+		api.limiter.reconcile(*input.Model, estimated, used)
+	}()
+	reqBody := new(bytes.Buffer)
+	if err := json.NewEncoder(reqBody).Encode(input); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := api.requestURL("/v1/completions", input.Model)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		endpoint.String(),
+		reqBody,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if err := api.setToken(req); err != nil {
+		return nil, err
+	}
+	resp, md, err := api.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		used = estimated
+		ret := &CompletionsV1Output{ResponseMetadata: md}
+		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+			return ret, err
+		}
+		if ret.Usage != nil {
+			used = ret.Usage.TotalTokens
+		}
+		api.recordUsage(ctx, &UsageRecord{
+			Endpoint: EndpointCompletionsV1,
+			Model:    *input.Model,
+			User:     lo.FromPtr(input.User),
+			Usage:    ret.Usage,
+		})
+		return ret, nil
+	case http.StatusUnauthorized:
+		ret := &CompletionsV1Output{ResponseMetadata: md}
+		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+			return ret, err
+		}
+		return ret, ErrUnauthorized
+	case http.StatusBadGateway:
+		buf := new(bytes.Buffer)
+		io.Copy(buf, resp.Body)
+		ret := &CompletionsV1Output{
+			ResponseMetadata: md,
+			Error: &Error{
+				Message: buf.String(),
+			},
+		}
+		return ret, xerrors.Errorf("msg: %s, error: %w", buf.String(), ErrStatusBadGateway)
+	default:
+		buf := new(bytes.Buffer)
+		io.Copy(buf, resp.Body)
+		ret := &CompletionsV1Output{
+			ResponseMetadata: md,
+			Error: &Error{
+				Message: buf.String(),
+			},
+		}
+		return ret, xerrors.Errorf("status_code: %d, msg: %s, error: %w", resp.StatusCode, buf.String(), ErrUnknown)
+	}
+}
+
+// stream chunks have the same shape as CompletionsV1Output
+func (api *OpenAIAPI) CompletionsV1Stream(ctx context.Context, input *CompletionsV1Input) (*Stream[CompletionsV1Output], error) {
+	return intercept(api, ctx, EndpointCompletionsV1Stream, input, api.completionsV1Stream)
+}
+
+func (api *OpenAIAPI) completionsV1Stream(ctx context.Context, input *CompletionsV1Input) (*Stream[CompletionsV1Output], error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if err := api.checkUsage(ctx, func() *UsageRecord {
+		return &UsageRecord{
+			Endpoint: EndpointCompletionsV1Stream,
+			Model:    *input.Model,
+			User:     lo.FromPtr(input.User),
+			Usage:    api.completionsUsageEstimate(input),
+		}
+	}); err != nil {
+		return nil, err
+	}
+	estimated := api.limiter.estimateCompletions(input)
+	if err := api.limiter.wait(ctx, *input.Model, estimated); err != nil {
+		return nil, err
+	}
+	req := *input
+	req.stream = true
+	if len(api.usageRecorders) > 0 && req.StreamOptions == nil {
+		// otherwise the usage is not sent
+		req.StreamOptions = &ChatCompletionsV1InputStreamOptions{IncludeUsage: lo.ToPtr(true)}
+	}
+	body, md, err := api.openStream(ctx, "/v1/completions", req.Model, &req)
+	if err != nil {
+		api.limiter.reconcile(*input.Model, estimated, 0)
+		return nil, err
+	}
+	ret := newStream[CompletionsV1Output](body, md)
+	// a stream which ends before the usage chunk is reconciled and recorded with the prompt estimate
+	// and one token for each chunk with choices
+	var usage *ChatCompletionsV1OutputUsage
+	chunks := 0
+	ret.onEvent = func(chunk *CompletionsV1Output) {
+		if len(chunk.Choices) > 0 {
+			chunks++
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+			api.recordUsage(ctx, &UsageRecord{
+				Endpoint: EndpointCompletionsV1Stream,
+				Model:    *input.Model,
+				User:     lo.FromPtr(input.User),
+				Usage:    chunk.Usage,
+			})
+		}
+	}
+	ret.onDone = func() {
+		if usage != nil {
+			api.limiter.reconcile(*input.Model, estimated, usage.TotalTokens)
+			return
+		}
+		estimate := estimatedTokenUsage(api.limiter.completionsPromptTokens(input), chunks)
+		api.limiter.reconcile(*input.Model, estimated, estimate.TotalTokens)
+		api.recordUsage(ctx, &UsageRecord{
+			Endpoint:  EndpointCompletionsV1Stream,
+			Model:     *input.Model,
+			User:      lo.FromPtr(input.User),
+			Usage:     estimate,
+			Estimated: true,
+		})
+	}
+	return ret, nil
+}
@@ -2,19 +2,36 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 
+	"github.com/samber/lo"
 	"golang.org/x/xerrors"
 )
 
+// the defaults of the api, used to price the calls which do not set them
+const (
+	DefaultImagesModel = "dall-e-2"
+	DefaultImageSize   = "1024x1024"
+)
+
 type ImagesGenerationsV1Input struct {
+	Model          *string `json:"model,omitempty"` // default: DefaultImagesModel
 	Prompt         *string `json:"prompt,omitempty"`
 	N              *int    `json:"n,omitempty"`
-	Size           *string `json:"size,omitempty"`
+	Size           *string `json:"size,omitempty"`    // default: DefaultImageSize
+	Quality        *string `json:"quality,omitempty"` // standard or hd for dall-e-3
 	ResponseFormat *string `json:"response_format,omitempty"`
 	User           *string `json:"user,omitempty"`
+
+	ExtraBody map[string]any `json:"-"` // merged into the request body, for vendor-specific parameters
+}
+
+func (input *ImagesGenerationsV1Input) MarshalJSON() ([]byte, error) {
+	type alias ImagesGenerationsV1Input
+	return marshalWithExtraBody((*alias)(input), input.ExtraBody)
 }
 
 func (impl *ImagesGenerationsV1Input) validate() error {
@@ -30,22 +47,57 @@ type ImagesGenerationsV1Output struct {
 		URL string `json:"url,omitempty"`
 	} `json:"data,omitempty"`
 	Error *Error `json:"error,omitempty"`
+
+	Raw              json.RawMessage            `json:"-"` // raw response body
+	ExtraFields      map[string]json.RawMessage `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata *ResponseMetadata          `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *ImagesGenerationsV1Output) UnmarshalJSON(data []byte) error {
+	type alias ImagesGenerationsV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
 }
 
 func (api *OpenAIAPI) ImagesGenerationsV1(input *ImagesGenerationsV1Input) (*ImagesGenerationsV1Output, error) {
+	return api.ImagesGenerationsV1WithContext(context.Background(), input)
+}
+
+func (api *OpenAIAPI) ImagesGenerationsV1WithContext(ctx context.Context, input *ImagesGenerationsV1Input) (*ImagesGenerationsV1Output, error) {
+	return intercept(api, ctx, EndpointImagesGenerationsV1, input, api.imagesGenerationsV1)
+}
+
+func (api *OpenAIAPI) imagesGenerationsV1(ctx context.Context, input *ImagesGenerationsV1Input) (*ImagesGenerationsV1Output, error) {
 	if err := input.validate(); err != nil {
 		return nil, err
 	}
+	if err := api.checkUsage(ctx, func() *UsageRecord {
+		return &UsageRecord{
+			Endpoint:     EndpointImagesGenerationsV1,
+			Model:        lo.FromPtrOr(input.Model, DefaultImagesModel),
+			User:         lo.FromPtr(input.User),
+			Images:       lo.FromPtrOr(input.N, 1),
+			ImageSize:    lo.FromPtrOr(input.Size, DefaultImageSize),
+			ImageQuality: lo.FromPtr(input.Quality),
+		}
+	}); err != nil {
+		return nil, err
+	}
 	reqBody := new(bytes.Buffer)
 	if err := json.NewEncoder(reqBody).Encode(input); err != nil {
 		return nil, err
 	}
-	endpoint, err := api.endpoint()
+	endpoint, err := api.requestURL("/v1/images/generations", input.Model)
 	if err != nil {
 		return nil, err
 	}
-	endpoint.Path = "/v1/images/generations"
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
 		endpoint.String(),
 		reqBody,
@@ -57,22 +109,30 @@ func (api *OpenAIAPI) ImagesGenerationsV1(input *ImagesGenerationsV1Input) (*Ima
 	if err := api.setToken(req); err != nil {
 		return nil, err
 	}
-	resp, err := api.httpClient.Do(req)
+	resp, md, err := api.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	ret := &ImagesGenerationsV1Output{}
+	ret := &ImagesGenerationsV1Output{ResponseMetadata: md}
 	switch resp.StatusCode {
 	case http.StatusOK:
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
+		api.recordUsage(ctx, &UsageRecord{
+			Endpoint:     EndpointImagesGenerationsV1,
+			Model:        lo.FromPtrOr(input.Model, DefaultImagesModel),
+			User:         lo.FromPtr(input.User),
+			Images:       len(ret.Data),
+			ImageSize:    lo.FromPtrOr(input.Size, DefaultImageSize),
+			ImageQuality: lo.FromPtr(input.Quality),
+		})
 		return ret, nil
 	case http.StatusUnauthorized:
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
 		return ret, ErrUnauthorized
 	default:
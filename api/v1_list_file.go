@@ -2,14 +2,18 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
 
 	"golang.org/x/xerrors"
 )
 
-type ListFileV1Input struct{}
+type ListFileV1Input struct {
+	ExtraQuery url.Values // added to the query string, for vendor-specific parameters
+}
 
 type ListFileV1Data struct {
 	ID        string `json:"id,omitempty"`
@@ -23,15 +27,41 @@ type ListFileV1Output struct {
 	Data   []ListFileV1Data `json:"data,omitempty"`
 	Object *string          `json:"object,omitempty"`
 	Error  *Error           `json:"error,omitempty"`
+
+	Raw              json.RawMessage            `json:"-"` // raw response body
+	ExtraFields      map[string]json.RawMessage `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata *ResponseMetadata          `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *ListFileV1Output) UnmarshalJSON(data []byte) error {
+	type alias ListFileV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
 }
 
 func (api *OpenAIAPI) ListFileV1(input *ListFileV1Input) (*ListFileV1Output, error) {
-	endpoint, err := api.endpoint()
+	return api.ListFileV1WithContext(context.Background(), input)
+}
+
+func (api *OpenAIAPI) ListFileV1WithContext(ctx context.Context, input *ListFileV1Input) (*ListFileV1Output, error) {
+	return intercept(api, ctx, EndpointListFileV1, input, api.listFileV1)
+}
+
+func (api *OpenAIAPI) listFileV1(ctx context.Context, input *ListFileV1Input) (*ListFileV1Output, error) {
+	endpoint, err := api.requestURL("/v1/files", nil)
 	if err != nil {
 		return nil, err
 	}
-	endpoint.Path = "/v1/files"
-	req, err := http.NewRequest(
+	if input != nil {
+		setExtraQuery(endpoint, input.ExtraQuery)
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodGet,
 		endpoint.String(),
 		nil,
@@ -39,8 +69,10 @@ func (api *OpenAIAPI) ListFileV1(input *ListFileV1Input) (*ListFileV1Output, err
 	if err != nil {
 		return nil, err
 	}
-	api.setToken(req)
-	resp, err := api.httpClient.Do(req)
+	if err := api.setToken(req); err != nil {
+		return nil, err
+	}
+	resp, md, err := api.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -48,21 +80,22 @@ func (api *OpenAIAPI) ListFileV1(input *ListFileV1Input) (*ListFileV1Output, err
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		ret := &ListFileV1Output{}
+		ret := &ListFileV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
 		return ret, nil
 	case http.StatusUnauthorized:
-		ret := &ListFileV1Output{}
+		ret := &ListFileV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
 		return ret, ErrUnauthorized
 	case http.StatusBadGateway:
 		buf := new(bytes.Buffer)
 		io.Copy(buf, resp.Body)
 		ret := &ListFileV1Output{
+			ResponseMetadata: md,
 			Error: &Error{
 				Message: buf.String(),
 			},
@@ -73,6 +106,7 @@ func (api *OpenAIAPI) ListFileV1(input *ListFileV1Input) (*ListFileV1Output, err
 		io.Copy(buf, resp.Body)
 
 		ret := &ListFileV1Output{
+			ResponseMetadata: md,
 			Error: &Error{
 				Message: buf.String(),
 			},
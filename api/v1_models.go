@@ -2,42 +2,84 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/samber/lo"
 	"golang.org/x/xerrors"
 )
 
-type ListModelsV1Permission struct {
-	ID                 string `json:"id,omitempty"`
-	Object             string `json:"object,omitempty"`
-	Created            int    `json:"created,omitempty"`
-	AllowCreateEngine  bool   `json:"allow_create_engine,omitempty"`
-	AllowSampling      bool   `json:"allow_sampling,omitempty"`
-	AllowLogprobs      bool   `json:"allow_logprobs,omitempty"`
-	AllowSearchIndices bool   `json:"allow_search_indices,omitempty"`
-	AllowView          bool   `json:"allow_view,omitempty"`
-	AllowFineTuning    bool   `json:"allow_fine_tuning,omitempty"`
-	Organization       string `json:"organization,omitempty"`
-	Group              any    `json:"group,omitempty"`
-	IsBlocking         bool   `json:"is_blocking,omitempty"`
+// doc: https://platform.openai.com/docs/api-reference/models/object
+type ListModelsV1Data struct {
+	ID      string `json:"id,omitempty"`
+	Object  string `json:"object,omitempty"`
+	Created int    `json:"created,omitempty"`
+	OwnedBy string `json:"owned_by,omitempty"`
 }
 
-type ListModelsV1Data struct {
-	ID         string                   `json:"id,omitempty"`
-	Object     string                   `json:"object,omitempty"`
-	Created    int                      `json:"created,omitempty"`
-	OwnedBy    string                   `json:"owned_by,omitempty"`
-	Permission []ListModelsV1Permission `json:"permission,omitempty"`
-	Root       string                   `json:"root,omitempty"`
-	Parent     any                      `json:"parent,omitempty"`
+// fine-tuned model IDs look like ft:gpt-4o-mini-2024-07-18:org-name::abc123
+func (impl ListModelsV1Data) IsFineTuned() bool {
+	return strings.HasPrefix(impl.ID, "ft:")
+}
+
+type ListModelsV1Filter func(ListModelsV1Data) bool
+
+func FilterFineTunedModels() ListModelsV1Filter {
+	return func(d ListModelsV1Data) bool {
+		return d.IsFineTuned()
+	}
+}
+
+func FilterModelsOwnedBy(ownedBy string) ListModelsV1Filter {
+	return func(d ListModelsV1Data) bool {
+		return d.OwnedBy == ownedBy
+	}
+}
+
+func FilterModelsByPrefix(prefix string) ListModelsV1Filter {
+	return func(d ListModelsV1Data) bool {
+		return strings.HasPrefix(d.ID, prefix)
+	}
 }
 
 type ListModelsV1Output struct {
 	Error  *Error             `json:"error,omitempty"`
 	Object string             `json:"object,omitempty"`
 	Data   []ListModelsV1Data `json:"data,omitempty"`
+
+	Raw              json.RawMessage            `json:"-"` // raw response body
+	ExtraFields      map[string]json.RawMessage `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata *ResponseMetadata          `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *ListModelsV1Output) UnmarshalJSON(data []byte) error {
+	type alias ListModelsV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
+}
+
+// return the models matching all filters.
+// example: list only the fine-tuned models of your organization
+//
+//	output.Filter(api.FilterFineTunedModels(), api.FilterModelsOwnedBy("org-xxxx"))
+func (impl *ListModelsV1Output) Filter(filters ...ListModelsV1Filter) []ListModelsV1Data {
+	return lo.Filter(impl.Data, func(d ListModelsV1Data, _ int) bool {
+		for _, f := range filters {
+			if !f(d) {
+				return false
+			}
+		}
+		return true
+	})
 }
 
 func (impl *ListModelsV1Output) String() string {
@@ -46,15 +88,28 @@ func (impl *ListModelsV1Output) String() string {
 	return buf.String()
 }
 
-type ListModelsV1Input struct{}
+type ListModelsV1Input struct {
+	ExtraQuery url.Values // added to the query string, for vendor-specific parameters
+}
+
+func (api *OpenAIAPI) ListModelsV1(input *ListModelsV1Input) (*ListModelsV1Output, error) {
+	return api.ListModelsV1WithContext(context.Background(), input)
+}
 
-func (api *OpenAIAPI) ListModelsV1(*ListModelsV1Input) (*ListModelsV1Output, error) {
-	endpoint, err := api.endpoint()
+func (api *OpenAIAPI) ListModelsV1WithContext(ctx context.Context, input *ListModelsV1Input) (*ListModelsV1Output, error) {
+	return intercept(api, ctx, EndpointListModelsV1, input, api.listModelsV1)
+}
+
+func (api *OpenAIAPI) listModelsV1(ctx context.Context, input *ListModelsV1Input) (*ListModelsV1Output, error) {
+	endpoint, err := api.requestURL("/v1/models", nil)
 	if err != nil {
 		return nil, err
 	}
-	endpoint.Path = "/v1/models"
-	req, err := http.NewRequest(
+	if input != nil {
+		setExtraQuery(endpoint, input.ExtraQuery)
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodGet,
 		endpoint.String(),
 		nil,
@@ -62,8 +117,10 @@ func (api *OpenAIAPI) ListModelsV1(*ListModelsV1Input) (*ListModelsV1Output, err
 	if err != nil {
 		return nil, err
 	}
-	api.setToken(req)
-	resp, err := api.httpClient.Do(req)
+	if err := api.setToken(req); err != nil {
+		return nil, err
+	}
+	resp, md, err := api.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -71,21 +128,215 @@ func (api *OpenAIAPI) ListModelsV1(*ListModelsV1Input) (*ListModelsV1Output, err
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		ret := &ListModelsV1Output{}
+		ret := &ListModelsV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
 		return ret, nil
 	case http.StatusUnauthorized:
-		ret := &ListModelsV1Output{}
+		ret := &ListModelsV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
 		return ret, ErrUnauthorized
 	default:
 		buf := new(bytes.Buffer)
 		io.Copy(buf, resp.Body)
 		ret := &ListModelsV1Output{
+			ResponseMetadata: md,
+			Error: &Error{
+				Message: buf.String(),
+			},
+		}
+
+		return ret, xerrors.Errorf("status_code: %d, msg: %s, error: %w", resp.StatusCode, buf.String(), ErrUnknown)
+	}
+}
+
+type RetrieveModelV1Input struct {
+	Model *string
+}
+
+func (impl *RetrieveModelV1Input) validate() error {
+	if impl.Model == nil || *impl.Model == "" {
+		return xerrors.New("no model")
+	}
+	return nil
+}
+
+type RetrieveModelV1Output struct {
+	ListModelsV1Data
+	Error *Error `json:"error,omitempty"`
+
+	Raw              json.RawMessage            `json:"-"` // raw response body
+	ExtraFields      map[string]json.RawMessage `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata *ResponseMetadata          `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *RetrieveModelV1Output) UnmarshalJSON(data []byte) error {
+	type alias RetrieveModelV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
+}
+
+func (impl *RetrieveModelV1Output) String() string {
+	buf := new(bytes.Buffer)
+	json.NewEncoder(buf).Encode(impl)
+	return buf.String()
+}
+
+func (api *OpenAIAPI) RetrieveModelV1(input *RetrieveModelV1Input) (*RetrieveModelV1Output, error) {
+	return api.RetrieveModelV1WithContext(context.Background(), input)
+}
+
+func (api *OpenAIAPI) RetrieveModelV1WithContext(ctx context.Context, input *RetrieveModelV1Input) (*RetrieveModelV1Output, error) {
+	return intercept(api, ctx, EndpointRetrieveModelV1, input, api.retrieveModelV1)
+}
+
+func (api *OpenAIAPI) retrieveModelV1(ctx context.Context, input *RetrieveModelV1Input) (*RetrieveModelV1Output, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	endpoint, err := api.requestURL("/v1/models/"+url.PathEscape(*input.Model), nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		endpoint.String(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.setToken(req); err != nil {
+		return nil, err
+	}
+	resp, md, err := api.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		ret := &RetrieveModelV1Output{ResponseMetadata: md}
+		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+			return ret, err
+		}
+		return ret, nil
+	case http.StatusUnauthorized:
+		ret := &RetrieveModelV1Output{ResponseMetadata: md}
+		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+			return ret, err
+		}
+		return ret, ErrUnauthorized
+	default:
+		buf := new(bytes.Buffer)
+		io.Copy(buf, resp.Body)
+		ret := &RetrieveModelV1Output{
+			ResponseMetadata: md,
+			Error: &Error{
+				Message: buf.String(),
+			},
+		}
+
+		return ret, xerrors.Errorf("status_code: %d, msg: %s, error: %w", resp.StatusCode, buf.String(), ErrUnknown)
+	}
+}
+
+// only fine-tuned models owned by your organization can be deleted
+type DeleteModelV1Input struct {
+	Model *string
+}
+
+func (impl *DeleteModelV1Input) validate() error {
+	if impl.Model == nil || *impl.Model == "" {
+		return xerrors.New("no model")
+	}
+	return nil
+}
+
+type DeleteModelV1Output struct {
+	ID      string `json:"id,omitempty"`
+	Object  string `json:"object,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+	Error   *Error `json:"error,omitempty"`
+
+	Raw              json.RawMessage            `json:"-"` // raw response body
+	ExtraFields      map[string]json.RawMessage `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata *ResponseMetadata          `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *DeleteModelV1Output) UnmarshalJSON(data []byte) error {
+	type alias DeleteModelV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
+}
+
+func (api *OpenAIAPI) DeleteModelV1(input *DeleteModelV1Input) (*DeleteModelV1Output, error) {
+	return api.DeleteModelV1WithContext(context.Background(), input)
+}
+
+func (api *OpenAIAPI) DeleteModelV1WithContext(ctx context.Context, input *DeleteModelV1Input) (*DeleteModelV1Output, error) {
+	return intercept(api, ctx, EndpointDeleteModelV1, input, api.deleteModelV1)
+}
+
+func (api *OpenAIAPI) deleteModelV1(ctx context.Context, input *DeleteModelV1Input) (*DeleteModelV1Output, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	endpoint, err := api.requestURL("/v1/models/"+url.PathEscape(*input.Model), nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodDelete,
+		endpoint.String(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.setToken(req); err != nil {
+		return nil, err
+	}
+	resp, md, err := api.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		ret := &DeleteModelV1Output{ResponseMetadata: md}
+		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+			return ret, err
+		}
+		return ret, nil
+	case http.StatusUnauthorized:
+		ret := &DeleteModelV1Output{ResponseMetadata: md}
+		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+			return ret, err
+		}
+		return ret, ErrUnauthorized
+	default:
+		buf := new(bytes.Buffer)
+		io.Copy(buf, resp.Body)
+		ret := &DeleteModelV1Output{
+			ResponseMetadata: md,
 			Error: &Error{
 				Message: buf.String(),
 			},
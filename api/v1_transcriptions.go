@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/samber/lo"
 	"golang.org/x/xerrors"
 )
 
@@ -28,6 +30,7 @@ type AudioTranscriptionsV1Input struct {
 	Temperature    *float32
 	ResponseFormat *string
 	Prompt         *string
+	ExtraBody      map[string]any // written as additional form fields, for vendor-specific parameters
 }
 
 func (impl *AudioTranscriptionsV1Input) validate() error {
@@ -60,6 +63,21 @@ type AudioTranscriptionsV1Output struct {
 	Segments []AudioTranscriptionsV1Segments `json:"segments,omitempty"`
 	Text     *string                         `json:"text,omitempty"`
 	Error    *Error                          `json:"error,omitempty"`
+
+	Raw              json.RawMessage            `json:"-"` // raw response body
+	ExtraFields      map[string]json.RawMessage `json:"-"` // fields which are not declared in this struct
+	ResponseMetadata *ResponseMetadata          `json:"-"` // metadata of the http response, also set when an error is returned
+}
+
+func (impl *AudioTranscriptionsV1Output) UnmarshalJSON(data []byte) error {
+	type alias AudioTranscriptionsV1Output
+	extra, err := unmarshalWithExtraFields(data, (*alias)(impl))
+	if err != nil {
+		return err
+	}
+	impl.Raw = append(json.RawMessage{}, data...)
+	impl.ExtraFields = extra
+	return nil
 }
 
 func (impl *AudioTranscriptionsV1Output) GoString() string {
@@ -69,10 +87,26 @@ func (impl *AudioTranscriptionsV1Output) GoString() string {
 }
 
 func (api *OpenAIAPI) AudioTranscriptionsV1(input *AudioTranscriptionsV1Input) (*AudioTranscriptionsV1Output, error) {
+	return api.AudioTranscriptionsV1WithContext(context.Background(), input)
+}
+
+func (api *OpenAIAPI) AudioTranscriptionsV1WithContext(ctx context.Context, input *AudioTranscriptionsV1Input) (*AudioTranscriptionsV1Output, error) {
+	return intercept(api, ctx, EndpointAudioTranscriptionsV1, input, api.audioTranscriptionsV1)
+}
+
+func (api *OpenAIAPI) audioTranscriptionsV1(ctx context.Context, input *AudioTranscriptionsV1Input) (*AudioTranscriptionsV1Output, error) {
 	if err := input.validate(); err != nil {
 		return nil, err
 	}
 	defer input.File.Close()
+	if err := api.checkUsage(ctx, func() *UsageRecord {
+		return &UsageRecord{
+			Endpoint: EndpointAudioTranscriptionsV1,
+			Model:    *input.Model,
+		}
+	}); err != nil {
+		return nil, err
+	}
 	payload := new(bytes.Buffer)
 	writer := multipart.NewWriter(payload)
 
@@ -110,15 +144,18 @@ func (api *OpenAIAPI) AudioTranscriptionsV1(input *AudioTranscriptionsV1Input) (
 	if err := writeField("prompt", writer, input.Prompt); err != nil {
 		return nil, err
 	}
+	if err := writeExtraFields(writer, input.ExtraBody); err != nil {
+		return nil, err
+	}
 	if err := writer.Close(); err != nil {
 		return nil, err
 	}
-	endpoint, err := api.endpoint()
+	endpoint, err := api.requestURL("/v1/audio/transcriptions", input.Model)
 	if err != nil {
 		return nil, err
 	}
-	endpoint.Path = "/v1/audio/transcriptions"
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
 		endpoint.String(),
 		payload,
@@ -130,7 +167,7 @@ func (api *OpenAIAPI) AudioTranscriptionsV1(input *AudioTranscriptionsV1Input) (
 	if err := api.setToken(req); err != nil {
 		return nil, err
 	}
-	resp, err := api.httpClient.Do(req)
+	resp, md, err := api.do(req)
 
 	if err != nil {
 		return nil, err
@@ -138,21 +175,28 @@ func (api *OpenAIAPI) AudioTranscriptionsV1(input *AudioTranscriptionsV1Input) (
 	defer resp.Body.Close()
 	switch resp.StatusCode {
 	case http.StatusOK:
-		ret := &AudioTranscriptionsV1Output{}
+		ret := &AudioTranscriptionsV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
+		api.recordUsage(ctx, &UsageRecord{
+			Endpoint:     EndpointAudioTranscriptionsV1,
+			Model:        *input.Model,
+			AudioSeconds: lo.FromPtr(ret.Duration),
+			Unmetered:    ret.Duration == nil,
+		})
 		return ret, nil
 	case http.StatusUnauthorized:
-		ret := &AudioTranscriptionsV1Output{}
+		ret := &AudioTranscriptionsV1Output{ResponseMetadata: md}
 		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
-			return nil, err
+			return ret, err
 		}
 		return ret, ErrUnauthorized
 	case http.StatusBadGateway:
 		buf := new(bytes.Buffer)
 		io.Copy(buf, resp.Body)
 		ret := &AudioTranscriptionsV1Output{
+			ResponseMetadata: md,
 			Error: &Error{
 				Message: buf.String(),
 			},
@@ -162,6 +206,7 @@ func (api *OpenAIAPI) AudioTranscriptionsV1(input *AudioTranscriptionsV1Input) (
 		buf := new(bytes.Buffer)
 		io.Copy(buf, resp.Body)
 		ret := &AudioTranscriptionsV1Output{
+			ResponseMetadata: md,
 			Error: &Error{
 				Message: buf.String(),
 			},
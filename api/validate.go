@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/danielgtaylor/huma/schema"
+	"github.com/samber/lo"
+)
+
+type ArgumentsValidationIssue struct {
+	Path    string `json:"path"` // example: $.items[0].name
+	Message string `json:"message"`
+}
+
+// returned when function calling arguments do not match the declared parameters schema.
+// The Error() string is intended to be sent back to the model for self-correction.
+type ArgumentsValidationError struct {
+	FunctionName string                     `json:"function_name,omitempty"`
+	Issues       []ArgumentsValidationIssue `json:"issues"`
+}
+
+func (e *ArgumentsValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", issue.Path, issue.Message))
+	}
+	return fmt.Sprintf("invalid arguments for function %s: %s", e.FunctionName, strings.Join(msgs, "; "))
+}
+
+func (e *ArgumentsValidationError) Unwrap() error {
+	return ErrParseFunctionCallingArguments
+}
+
+// validate function calling arguments against the parameters schema of f.
+// If the arguments are invalid, *ArgumentsValidationError is returned.
+func (f *Function) ValidateArguments(arguments string) error {
+	issues := validateJSON(f.Paramaters, arguments)
+	if len(issues) == 0 {
+		return nil
+	}
+	return &ArgumentsValidationError{
+		FunctionName: f.Name,
+		Issues:       issues,
+	}
+}
+
+func validateJSON(s *schema.Schema, data string) []ArgumentsValidationIssue {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []ArgumentsValidationIssue{{Path: "$", Message: fmt.Sprintf("malformed json: %v", err)}}
+	}
+	if dec.More() {
+		return []ArgumentsValidationIssue{{Path: "$", Message: "malformed json: unexpected data after top-level value"}}
+	}
+	return validateValue(s, v, "$")
+}
+
+func validateValue(s *schema.Schema, v any, path string) []ArgumentsValidationIssue {
+	if s == nil {
+		return nil
+	}
+
+	if len(s.AnyOf) != 0 {
+		for _, sub := range s.AnyOf {
+			if len(validateValue(sub, v, path)) == 0 {
+				return nil
+			}
+		}
+		return []ArgumentsValidationIssue{{Path: path, Message: "does not match any of the allowed schemas"}}
+	}
+
+	if v == nil {
+		if s.Type == "" || s.Type == "null" || s.Nullable {
+			return nil
+		}
+		return []ArgumentsValidationIssue{{Path: path, Message: fmt.Sprintf("expected %s, got null", s.Type)}}
+	}
+
+	if s.Type != "" && !matchType(s.Type, v) {
+		return []ArgumentsValidationIssue{{Path: path, Message: fmt.Sprintf("expected %s, got %s", s.Type, jsonTypeName(v))}}
+	}
+
+	ret := []ArgumentsValidationIssue{}
+	if len(s.Enum) != 0 && !matchEnum(s.Enum, v) {
+		ret = append(ret, ArgumentsValidationIssue{Path: path, Message: fmt.Sprintf("must be one of %s", formatEnum(s.Enum))})
+	}
+
+	switch tv := v.(type) {
+	case json.Number:
+		f, err := tv.Float64()
+		if err != nil {
+			break
+		}
+		if s.Minimum != nil && (f < *s.Minimum || (s.ExclusiveMinimum != nil && *s.ExclusiveMinimum && f == *s.Minimum)) {
+			ret = append(ret, ArgumentsValidationIssue{Path: path, Message: fmt.Sprintf("must be greater than or equal to %v", *s.Minimum)})
+		}
+		if s.Maximum != nil && (f > *s.Maximum || (s.ExclusiveMaximum != nil && *s.ExclusiveMaximum && f == *s.Maximum)) {
+			ret = append(ret, ArgumentsValidationIssue{Path: path, Message: fmt.Sprintf("must be less than or equal to %v", *s.Maximum)})
+		}
+	case []any:
+		for i, item := range tv {
+			ret = append(ret, validateValue(s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := tv[name]; !ok {
+				ret = append(ret, ArgumentsValidationIssue{Path: path + "." + name, Message: "required property is missing"})
+			}
+		}
+		keys := make([]string, 0, len(tv))
+		for k := range tv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				// null is accepted for optional properties in the same way as json.Unmarshal
+				if tv[k] == nil && !lo.Contains(s.Required, k) {
+					continue
+				}
+				ret = append(ret, validateValue(p, tv[k], path+"."+k)...)
+				continue
+			}
+			switch ap := s.AdditionalProperties.(type) {
+			case bool:
+				if !ap {
+					ret = append(ret, ArgumentsValidationIssue{Path: path + "." + k, Message: "unknown property"})
+				}
+			case *schema.Schema:
+				ret = append(ret, validateValue(ap, tv[k], path+"."+k)...)
+			}
+		}
+	}
+	return ret
+}
+
+func matchType(typ string, v any) bool {
+	switch typ {
+	case schema.TypeObject:
+		_, ok := v.(map[string]any)
+		return ok
+	case schema.TypeArray:
+		_, ok := v.([]any)
+		return ok
+	case schema.TypeString:
+		_, ok := v.(string)
+		return ok
+	case schema.TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case schema.TypeNumber:
+		_, ok := v.(json.Number)
+		return ok
+	case schema.TypeInteger:
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return schema.TypeObject
+	case []any:
+		return schema.TypeArray
+	case string:
+		return schema.TypeString
+	case bool:
+		return schema.TypeBoolean
+	case json.Number:
+		return schema.TypeNumber
+	case nil:
+		return "null"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+func matchEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if n, ok := v.(json.Number); ok {
+			f, err := n.Float64()
+			if err != nil {
+				continue
+			}
+			if ef, ok := toFloat64(e); ok && ef == f {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat64(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func formatEnum(enum []any) string {
+	buf := new(bytes.Buffer)
+	json.NewEncoder(buf).Encode(enum)
+	return strings.TrimSpace(buf.String())
+}
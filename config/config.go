@@ -1,7 +1,86 @@
 package config
 
+import (
+	"context"
+	"fmt"
+)
+
 type Configuration struct {
 	ApiKey       *string
-	Organization *string
-	Endpoint     *string //default: https://api.openai.com
+	Organization *string             // optional
+	Project      *string             // optional
+	Credentials  CredentialsProvider // if set, it is resolved on each request instead of ApiKey, Organization and Project
+	Endpoint     *string             //default: https://api.openai.com, for Azure: https://{resource}.openai.azure.com
+	Azure        *AzureConfiguration // enables the Azure OpenAI deployment mode
+}
+
+// resolve the credentials for a request
+func (cfg *Configuration) ResolveCredentials(ctx context.Context) (*Credentials, error) {
+	if cfg.Credentials != nil {
+		return cfg.Credentials.Credentials(ctx)
+	}
+	ret := &Credentials{}
+	if cfg.ApiKey != nil {
+		ret.ApiKey = *cfg.ApiKey
+	}
+	if cfg.Organization != nil {
+		ret.Organization = *cfg.Organization
+	}
+	if cfg.Project != nil {
+		ret.Project = *cfg.Project
+	}
+	return ret, nil
+}
+
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// returns a Microsoft Entra ID (Azure AD) access token for https://cognitiveservices.azure.com/.default
+type AzureTokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+type AzureTokenSourceFunc func(ctx context.Context) (string, error)
+
+func (f AzureTokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// doc: https://learn.microsoft.com/azure/ai-services/openai/reference
+type AzureConfiguration struct {
+	APIVersion  string            // default: DefaultAzureAPIVersion
+	Deployments map[string]string // model name to deployment name. If a model is not found, the model name is used as the deployment name.
+	TokenSource AzureTokenSource  // if nil, ApiKey is sent with the api-key header
+}
+
+func (cfg *AzureConfiguration) Deployment(model string) string {
+	if d, ok := cfg.Deployments[model]; ok {
+		return d
+	}
+	return model
+}
+
+func (cfg *AzureConfiguration) Version() string {
+	if cfg.APIVersion == "" {
+		return DefaultAzureAPIVersion
+	}
+	return cfg.APIVersion
+}
+
+// the api key is redacted
+func (cfg *Configuration) String() string {
+	field := func(v *string) string {
+		if v == nil {
+			return "<unset>"
+		}
+		return *v
+	}
+	apiKey := "<unset>"
+	if cfg.ApiKey != nil {
+		apiKey = RedactKey(*cfg.ApiKey)
+	}
+	return fmt.Sprintf("api_key: %s, organization: %s, project: %s, endpoint: %s", apiKey, field(cfg.Organization), field(cfg.Project), field(cfg.Endpoint))
+}
+
+func (cfg *Configuration) GoString() string {
+	return cfg.String()
 }
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type Credentials struct {
+	ApiKey       string
+	Organization string // optional, sent with the OpenAI-Organization header
+	Project      string // optional, sent with the OpenAI-Project header
+}
+
+// resolved on each request, so keys can be rotated without rebuilding the client
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+type CredentialsProviderFunc func(ctx context.Context) (*Credentials, error)
+
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (*Credentials, error) {
+	return f(ctx)
+}
+
+type staticCredentialsProvider struct {
+	credentials Credentials
+}
+
+func NewStaticCredentialsProvider(apiKey, organization, project string) CredentialsProvider {
+	return &staticCredentialsProvider{
+		credentials: Credentials{
+			ApiKey:       apiKey,
+			Organization: organization,
+			Project:      project,
+		},
+	}
+}
+
+func (p *staticCredentialsProvider) Credentials(context.Context) (*Credentials, error) {
+	ret := p.credentials
+	return &ret, nil
+}
+
+// reads the api key from a file and reloads it when the modification time changes
+type FileCredentialsProvider struct {
+	Path         string
+	Organization string
+	Project      string
+
+	mu      sync.Mutex
+	modTime time.Time
+	apiKey  string
+}
+
+func NewFileCredentialsProvider(path string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{
+		Path: path,
+	}
+}
+
+func (p *FileCredentialsProvider) Credentials(context.Context) (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to stat credentials file: %w", err)
+	}
+	if p.apiKey == "" || !info.ModTime().Equal(p.modTime) {
+		b, err := os.ReadFile(p.Path)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read credentials file: %w", err)
+		}
+		p.apiKey = strings.TrimSpace(string(b))
+		p.modTime = info.ModTime()
+	}
+	return &Credentials{
+		ApiKey:       p.apiKey,
+		Organization: p.Organization,
+		Project:      p.Project,
+	}, nil
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvApiKey       = "OPENAI_API_KEY"
+	EnvOrganization = "OPENAI_ORG_ID"
+	EnvProject      = "OPENAI_PROJECT_ID"
+	EnvBaseURL      = "OPENAI_BASE_URL"
+	EnvProfile      = "OPENAI_PROFILE"
+	EnvConfigFile   = "OPENAI_CONFIG_FILE"
+
+	DefaultProfile = "default"
+)
+
+// where a configuration value came from, for example env:OPENAI_API_KEY or file:/etc/openai.yaml#production
+type Source string
+
+const SourceUnset Source = ""
+
+func envSource(name string) Source {
+	return Source("env:" + name)
+}
+
+func fileSource(path, profile string) Source {
+	return Source(fmt.Sprintf("file:%s#%s", path, profile))
+}
+
+// a named profile in a config file.
+// example of yaml:
+//
+//	default:
+//	  api_key: sk-xxxx
+//	production:
+//	  api_key: sk-yyyy
+//	  organization: org-xxxx
+//	  project: proj_xxxx
+//	  base_url: https://api.openai.com/v1
+type Profile struct {
+	ApiKey       string `json:"api_key,omitempty" yaml:"api_key,omitempty" toml:"api_key,omitempty"`
+	Organization string `json:"organization,omitempty" yaml:"organization,omitempty" toml:"organization,omitempty"`
+	Project      string `json:"project,omitempty" yaml:"project,omitempty" toml:"project,omitempty"`
+	BaseURL      string `json:"base_url,omitempty" yaml:"base_url,omitempty" toml:"base_url,omitempty"`
+}
+
+type LoadOptions struct {
+	File    string              // yaml, toml or json file chosen by the extension. default: OPENAI_CONFIG_FILE, optional
+	Profile string              // default: OPENAI_PROFILE or "default"
+	Getenv  func(string) string // default: os.Getenv
+}
+
+// configuration with the source of each value.
+// Printing it redacts the api key.
+type LoadedConfiguration struct {
+	*Configuration
+	ApiKeySource       Source
+	OrganizationSource Source
+	ProjectSource      Source
+	EndpointSource     Source
+}
+
+// load the configuration from a profile file and the environment variables.
+// Environment variables take precedence over the profile file.
+func Load(opts *LoadOptions) (*LoadedConfiguration, error) {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+	getenv := opts.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	ret := &LoadedConfiguration{
+		Configuration: &Configuration{},
+	}
+
+	file := opts.File
+	if file == "" {
+		file = getenv(EnvConfigFile)
+	}
+	if file != "" {
+		profileName := opts.Profile
+		if profileName == "" {
+			profileName = getenv(EnvProfile)
+		}
+		if profileName == "" {
+			profileName = DefaultProfile
+		}
+		profile, err := LoadProfile(file, profileName)
+		if err != nil {
+			return nil, err
+		}
+		src := fileSource(file, profileName)
+		ret.set(&ret.ApiKey, &ret.ApiKeySource, profile.ApiKey, src)
+		ret.set(&ret.Organization, &ret.OrganizationSource, profile.Organization, src)
+		ret.set(&ret.Project, &ret.ProjectSource, profile.Project, src)
+		ret.set(&ret.Endpoint, &ret.EndpointSource, normalizeBaseURL(profile.BaseURL), src)
+	}
+
+	ret.set(&ret.ApiKey, &ret.ApiKeySource, getenv(EnvApiKey), envSource(EnvApiKey))
+	ret.set(&ret.Organization, &ret.OrganizationSource, getenv(EnvOrganization), envSource(EnvOrganization))
+	ret.set(&ret.Project, &ret.ProjectSource, getenv(EnvProject), envSource(EnvProject))
+	ret.set(&ret.Endpoint, &ret.EndpointSource, normalizeBaseURL(getenv(EnvBaseURL)), envSource(EnvBaseURL))
+
+	if err := ret.Validate(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (cfg *LoadedConfiguration) set(dst **string, dstSource *Source, value string, src Source) {
+	if value == "" {
+		return
+	}
+	*dst = &value
+	*dstSource = src
+}
+
+// load a named profile from a yaml, toml or json file
+func LoadProfile(path, profile string) (*Profile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read config file: %w", err)
+	}
+	profiles := map[string]Profile{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		err = dec.Decode(&profiles)
+		if err == io.EOF {
+			// empty file
+			err = nil
+		}
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(b), &profiles)
+		if err == nil && len(md.Undecoded()) > 0 {
+			err = xerrors.Errorf("unknown fields: %v", md.Undecoded())
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&profiles)
+	default:
+		return nil, xerrors.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse config file: %s: %w", path, err)
+	}
+	ret, ok := profiles[profile]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, xerrors.Errorf("profile: %s is not found in %s, available profiles: %s", profile, path, strings.Join(names, ", "))
+	}
+	return &ret, nil
+}
+
+// OPENAI_BASE_URL includes /v1 in the official SDKs, but Endpoint does not.
+// Only the trailing /v1 is removed, the rest of the path such as /openai of https://gw/openai/v1
+// is kept and the request paths are joined to it.
+func normalizeBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return strings.TrimSuffix(baseURL, "/v1")
+}
+
+func (cfg *LoadedConfiguration) Validate() error {
+	if cfg.ApiKey == nil || *cfg.ApiKey == "" {
+		return xerrors.Errorf("api key is not set, set %s or api_key in the config file", EnvApiKey)
+	}
+	if cfg.Endpoint != nil {
+		u, err := url.Parse(*cfg.Endpoint)
+		if err != nil {
+			return xerrors.Errorf("invalid base url from %s: %w", cfg.EndpointSource, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return xerrors.Errorf("invalid base url from %s: %s", cfg.EndpointSource, *cfg.Endpoint)
+		}
+	}
+	return nil
+}
+
+func (cfg *LoadedConfiguration) String() string {
+	field := func(v *string, src Source) string {
+		if v == nil {
+			return "<unset>"
+		}
+		return fmt.Sprintf("%s (%s)", *v, src)
+	}
+	apiKey := "<unset>"
+	if cfg.ApiKey != nil {
+		apiKey = fmt.Sprintf("%s (%s)", RedactKey(*cfg.ApiKey), cfg.ApiKeySource)
+	}
+	return fmt.Sprintf(
+		"api_key: %s, organization: %s, project: %s, endpoint: %s",
+		apiKey,
+		field(cfg.Organization, cfg.OrganizationSource),
+		field(cfg.Project, cfg.ProjectSource),
+		field(cfg.Endpoint, cfg.EndpointSource),
+	)
+}
+
+func (cfg *LoadedConfiguration) GoString() string {
+	return cfg.String()
+}
+
+// redact an api key keeping only the prefix and the last 4 characters, for example sk-...abcd
+func RedactKey(key string) string {
+	if len(key) <= 12 {
+		return strings.Repeat("*", len(key))
+	}
+	prefix := ""
+	if i := strings.LastIndex(key[:8], "-"); i >= 0 {
+		prefix = key[:i+1]
+	}
+	return prefix + "..." + key[len(key)-4:]
+}
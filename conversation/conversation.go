@@ -0,0 +1,317 @@
+// Package conversation keeps the message history of a chat within a token budget.
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"github.com/ieee0824/gopenai-api/tokenizer"
+	"golang.org/x/xerrors"
+)
+
+var ErrNoChoice = xerrors.New("output has no choice")
+
+// the name of the system message which holds the summary of the trimmed turns
+const SummaryName = "conversation_summary"
+
+// context window of the models, used when TokenBudget is 0. Looked up with api.LookupModel.
+var ContextWindows = map[string]int{
+	"gpt-3.5-turbo":          16385,
+	"gpt-3.5-turbo-instruct": 4096,
+	"gpt-4":                  8192,
+	"gpt-4-32k":              32768,
+	"gpt-4-turbo":            128000,
+	"gpt-4-turbo-preview":    128000,
+	"gpt-4o":                 128000,
+	"gpt-4o-mini":            128000,
+	"chatgpt-4o-latest":      128000,
+	"gpt-4.1":                1047576,
+	"gpt-4.1-mini":           1047576,
+	"gpt-4.1-nano":           1047576,
+	"gpt-5":                  400000,
+	"gpt-5-mini":             400000,
+	"gpt-5-nano":             400000,
+	"o1":                     200000,
+	"o1-mini":                128000,
+	"o1-preview":             128000,
+	"o1-pro":                 200000,
+	"o3":                     200000,
+	"o3-mini":                200000,
+	"o3-pro":                 200000,
+	"o4-mini":                200000,
+}
+
+// context window of a model, fine-tuned models use the window of the base model
+func ContextWindow(model string) (int, bool) {
+	if base, ok := api.FineTunedBaseModel(model); ok {
+		model = base
+	}
+	return api.LookupModel(ContextWindows, model)
+}
+
+// summarize the dropped messages. The previous summary, if any, is the first message.
+type Summarizer interface {
+	Summarize(ctx context.Context, messages []api.Message) (string, error)
+}
+
+type SummarizerFunc func(ctx context.Context, messages []api.Message) (string, error)
+
+func (f SummarizerFunc) Summarize(ctx context.Context, messages []api.Message) (string, error) {
+	return f(ctx, messages)
+}
+
+// count the prompt tokens of messages
+type TokenCounter interface {
+	CountChatTokens(messages []api.Message, tools []*api.Tool) int
+}
+
+// message history of a chat.
+// System messages are pinned at the head, and the oldest turns are dropped, or summarized if
+// Summarizer is set, when Trim is called and the history exceeds the token budget.
+// An assistant message with tool calls and its tool results are always dropped together.
+// It is safe for concurrent use.
+type Conversation struct {
+	Model          string
+	TokenBudget    int          // default: ContextWindows of Model minus ReservedTokens
+	ReservedTokens int          // tokens reserved for tools and the completion when TokenBudget is 0
+	Tools          []*api.Tool  // counted in the budget
+	Summarizer     Summarizer   // optional
+	Counter        TokenCounter // default: the encoding of Model, or o200k_base for unknown models
+
+	mu       sync.Mutex
+	system   []api.Message
+	summary  *api.Message
+	messages []api.Message
+	version  int // incremented when the summary or the head of messages is replaced
+}
+
+func New(model string, tokenBudget int) *Conversation {
+	return &Conversation{
+		Model:       model,
+		TokenBudget: tokenBudget,
+	}
+}
+
+func (c *Conversation) AddSystem(content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.system = append(c.system, api.Message{Role: "system", Content: content})
+}
+
+func (c *Conversation) AddUser(content string) {
+	c.Add(api.Message{Role: "user", Content: content})
+}
+
+// append messages, system messages are pinned.
+// A summary written by Trim replaces the current summary, so that it can be trimmed again after Load.
+func (c *Conversation) Add(messages ...api.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range messages {
+		if m.Role == "system" && m.Name == SummaryName {
+			m := m
+			c.summary = &m
+			c.version++
+			continue
+		}
+		if m.Role == "system" || m.Role == "developer" {
+			c.system = append(c.system, m)
+			continue
+		}
+		c.messages = append(c.messages, m)
+	}
+}
+
+// append the assistant message of the first choice
+func (c *Conversation) AddOutput(output *api.ChatCompletionsV1Output) error {
+	if output == nil || len(output.Choices) == 0 {
+		return ErrNoChoice
+	}
+	c.Add(output.Choices[0].Message.ToMessage())
+	return nil
+}
+
+func (c *Conversation) AddToolResult(toolCallID, content string) {
+	c.Add(api.NewToolMessage(toolCallID, content))
+}
+
+// pinned system messages, the summary of the dropped turns and the recent turns
+func (c *Conversation) Messages() []api.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.all(c.messages)
+}
+
+// must be called with c.mu held
+func (c *Conversation) all(messages []api.Message) []api.Message {
+	ret := make([]api.Message, 0, len(c.system)+1+len(messages))
+	ret = append(ret, c.system...)
+	if c.summary != nil {
+		ret = append(ret, *c.summary)
+	}
+	return append(ret, messages...)
+}
+
+func (c *Conversation) budget() int {
+	if c.TokenBudget > 0 {
+		return c.TokenBudget
+	}
+	if w, ok := ContextWindow(c.Model); ok {
+		return w - c.ReservedTokens
+	}
+	return 0
+}
+
+func (c *Conversation) counter() (TokenCounter, error) {
+	if c.Counter != nil {
+		return c.Counter, nil
+	}
+	e, err := tokenizer.EncodingForModel(c.Model)
+	if xerrors.Is(err, tokenizer.ErrUnknownModel) {
+		return tokenizer.GetEncoding(tokenizer.O200kBase)
+	}
+	return e, err
+}
+
+// prompt tokens of the messages and the tools
+func (c *Conversation) Tokens() (int, error) {
+	counter, err := c.counter()
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return counter.CountChatTokens(c.all(c.messages), c.Tools), nil
+}
+
+// split messages into units which must be kept or dropped together,
+// an assistant message with tool calls and the following tool results are one unit
+func units(messages []api.Message) [][]api.Message {
+	ret := [][]api.Message{}
+	for _, m := range messages {
+		if m.Role == "tool" && len(ret) > 0 {
+			ret[len(ret)-1] = append(ret[len(ret)-1], m)
+			continue
+		}
+		ret = append(ret, []api.Message{m})
+	}
+	return ret
+}
+
+// drop or summarize the oldest turns until the history fits the token budget.
+// The last turn is always kept even if it exceeds the budget.
+// The lock is not held while summarizing, the messages added meanwhile are kept.
+func (c *Conversation) Trim(ctx context.Context) error {
+	counter, err := c.counter()
+	if err != nil {
+		return err
+	}
+	for {
+		done, err := c.trim(ctx, counter)
+		if done || err != nil {
+			return err
+		}
+		// the summary may push the history over the budget again
+	}
+}
+
+// one round of Trim, it returns true when the history fits the budget
+func (c *Conversation) trim(ctx context.Context, counter TokenCounter) (bool, error) {
+	c.mu.Lock()
+	budget := c.budget()
+	if budget <= 0 {
+		c.mu.Unlock()
+		return true, nil
+	}
+	us := units(c.messages)
+	dropped := []api.Message{}
+	kept := c.messages
+	for len(us) > 1 && counter.CountChatTokens(c.all(kept), c.Tools) > budget {
+		dropped = append(dropped, us[0]...)
+		kept = kept[len(us[0]):]
+		us = us[1:]
+	}
+	if len(dropped) == 0 {
+		c.mu.Unlock()
+		return true, nil
+	}
+	summarizer := c.Summarizer
+	if summarizer == nil {
+		c.messages = append([]api.Message{}, kept...)
+		c.version++
+		c.mu.Unlock()
+		return true, nil
+	}
+	input := dropped
+	if c.summary != nil {
+		input = append([]api.Message{*c.summary}, dropped...)
+	}
+	version := c.version
+	c.mu.Unlock()
+
+	summary, err := summarizer.Summarize(ctx, input)
+	if err != nil {
+		return false, xerrors.Errorf("failed to summarize the conversation: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != version {
+		// trimmed or loaded meanwhile, start over
+		return false, nil
+	}
+	c.summary = &api.Message{
+		Role:    "system",
+		Name:    SummaryName,
+		Content: "Summary of the earlier conversation: " + strings.TrimSpace(summary),
+	}
+	c.messages = append([]api.Message{}, c.messages[len(dropped):]...)
+	c.version++
+	return false, nil
+}
+
+type conversationJSON struct {
+	Model          string        `json:"model"`
+	TokenBudget    int           `json:"token_budget,omitempty"`
+	ReservedTokens int           `json:"reserved_tokens,omitempty"`
+	Tools          []*api.Tool   `json:"tools,omitempty"`
+	System         []api.Message `json:"system,omitempty"`
+	Summary        *api.Message  `json:"summary,omitempty"`
+	Messages       []api.Message `json:"messages"`
+}
+
+// Summarizer and Counter are not serialized
+func (c *Conversation) MarshalJSON() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return json.Marshal(&conversationJSON{
+		Model:          c.Model,
+		TokenBudget:    c.TokenBudget,
+		ReservedTokens: c.ReservedTokens,
+		Tools:          c.Tools,
+		System:         c.system,
+		Summary:        c.summary,
+		Messages:       c.messages,
+	})
+}
+
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	v := &conversationJSON{}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Model = v.Model
+	c.TokenBudget = v.TokenBudget
+	c.ReservedTokens = v.ReservedTokens
+	c.Tools = v.Tools
+	c.system = v.System
+	c.summary = v.Summary
+	c.messages = v.Messages
+	c.version++
+	return nil
+}
@@ -0,0 +1,81 @@
+package conversation
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"golang.org/x/xerrors"
+)
+
+var (
+	ErrTranscriptNotFound = xerrors.New("transcript not found")
+	ErrTranscriptExists   = xerrors.New("transcript already exists")
+	ErrVersionConflict    = xerrors.New("transcript version conflict")
+	ErrInvalidTranscript  = xerrors.New("invalid transcript")
+)
+
+// persisted messages of a conversation
+type Transcript struct {
+	ID        string            `json:"id"`
+	UserID    string            `json:"user_id,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Messages  []api.Message     `json:"messages"`
+	Version   int               `json:"version"` // 0 on create, incremented by each Append
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// storage of transcripts
+type Store interface {
+	// ErrTranscriptExists is returned if the id is already used
+	Create(ctx context.Context, t *Transcript) error
+	// ErrTranscriptNotFound is returned if the id is not found
+	Get(ctx context.Context, id string) (*Transcript, error)
+	// append messages if the version of the transcript is still version, and return the new version.
+	// ErrVersionConflict is returned if another append happened since the transcript was read.
+	Append(ctx context.Context, id string, version int, messages ...api.Message) (int, error)
+	// transcripts of a user, the most recently updated first
+	ListByUser(ctx context.Context, userID string) ([]*Transcript, error)
+	Delete(ctx context.Context, id string) error
+	// delete the transcripts which are not updated within ttl, and return the number of deleted transcripts
+	Prune(ctx context.Context, ttl time.Duration) (int, error)
+}
+
+var transcriptIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func validateTranscriptID(id string) error {
+	if !transcriptIDPattern.MatchString(id) || id == "." || id == ".." {
+		return xerrors.Errorf("id: %q, error: %w", id, ErrInvalidTranscript)
+	}
+	return nil
+}
+
+// prepare a transcript for Create
+func newTranscript(t *Transcript, now time.Time) (*Transcript, error) {
+	if err := validateTranscriptID(t.ID); err != nil {
+		return nil, err
+	}
+	ret := *t
+	ret.Messages = append([]api.Message{}, t.Messages...)
+	ret.Version = 0
+	if ret.CreatedAt.IsZero() {
+		ret.CreatedAt = now
+	}
+	if ret.UpdatedAt.IsZero() {
+		ret.UpdatedAt = ret.CreatedAt
+	}
+	return &ret, nil
+}
+
+// load the messages of a transcript into a conversation
+func (c *Conversation) Load(t *Transcript) {
+	c.mu.Lock()
+	c.system = nil
+	c.summary = nil
+	c.messages = nil
+	c.version++
+	c.mu.Unlock()
+	c.Add(t.Messages...)
+}
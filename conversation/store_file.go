@@ -0,0 +1,242 @@
+package conversation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"golang.org/x/xerrors"
+)
+
+// a line of a transcript file.
+// The first line is the header and the following lines are the appended messages.
+type fileRecord struct {
+	Header    *Transcript   `json:"header,omitempty"`
+	Version   int           `json:"version,omitempty"`
+	Messages  []api.Message `json:"messages,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at,omitempty"`
+}
+
+// stores each transcript as a JSONL file {id}.jsonl in Dir.
+// Appends are serialized in the process, use SQLiteStore when several processes share the transcripts.
+type FileStore struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, xerrors.Errorf("failed to create the directory: %w", err)
+	}
+	return &FileStore{
+		Dir: dir,
+	}, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.Dir, id+".jsonl")
+}
+
+func (s *FileStore) Create(ctx context.Context, t *Transcript) error {
+	t, err := newTranscript(t, time.Now())
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path(t.ID), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if os.IsExist(err) {
+		return xerrors.Errorf("id: %s, error: %w", t.ID, ErrTranscriptExists)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(&fileRecord{Header: t}); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (s *FileStore) Get(ctx context.Context, id string) (*Transcript, error) {
+	if err := validateTranscriptID(id); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(s.path(id))
+}
+
+// must be called with s.mu held
+func (s *FileStore) read(path string) (*Transcript, error) {
+	ret, _, err := s.readFile(path)
+	return ret, err
+}
+
+// read a transcript and the size of the complete lines
+func (s *FileStore) readFile(path string) (*Transcript, int64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, 0, xerrors.Errorf("path: %s, error: %w", path, ErrTranscriptNotFound)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var ret *Transcript
+	size := int64(0)
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			// an incomplete last line is a torn write, it was never acknowledged
+			if line[len(line)-1] != '\n' {
+				break
+			}
+			size += int64(len(line))
+			r := &fileRecord{}
+			if err := json.Unmarshal(line, r); err != nil {
+				return nil, 0, xerrors.Errorf("path: %s, error: %w", path, err)
+			}
+			switch {
+			case r.Header != nil:
+				ret = r.Header
+			case ret == nil:
+				return nil, 0, xerrors.Errorf("path: %s, error: no header: %w", path, ErrInvalidTranscript)
+			default:
+				ret.Messages = append(ret.Messages, r.Messages...)
+				ret.Version = r.Version
+				ret.UpdatedAt = r.UpdatedAt
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if ret == nil {
+		return nil, 0, xerrors.Errorf("path: %s, error: no header: %w", path, ErrInvalidTranscript)
+	}
+	return ret, size, nil
+}
+
+func (s *FileStore) Append(ctx context.Context, id string, version int, messages ...api.Message) (int, error) {
+	if err := validateTranscriptID(id); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, size, err := s.readFile(s.path(id))
+	if err != nil {
+		return 0, err
+	}
+	if t.Version != version {
+		return 0, xerrors.Errorf("id: %s, expected: %d, actual: %d, error: %w", id, version, t.Version, ErrVersionConflict)
+	}
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	// drop a torn write
+	if err := f.Truncate(size); err != nil {
+		return 0, err
+	}
+	r := &fileRecord{
+		Version:   version + 1,
+		Messages:  messages,
+		UpdatedAt: time.Now(),
+	}
+	if err := json.NewEncoder(f).Encode(r); err != nil {
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+	return r.Version, nil
+}
+
+// must be called with s.mu held
+func (s *FileStore) all() ([]*Transcript, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Transcript, 0, len(paths))
+	for _, path := range paths {
+		t, err := s.read(path)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
+
+func (s *FileStore) ListByUser(ctx context.Context, userID string) ([]*Transcript, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.all()
+	if err != nil {
+		return nil, err
+	}
+	ret := []*Transcript{}
+	for _, t := range all {
+		if t.UserID == userID {
+			ret = append(ret, t)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].UpdatedAt.After(ret[j].UpdatedAt)
+	})
+	return ret, nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	if err := validateTranscriptID(id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return xerrors.Errorf("id: %s, error: %w", id, ErrTranscriptNotFound)
+	}
+	return err
+}
+
+func (s *FileStore) Prune(ctx context.Context, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.jsonl"))
+	if err != nil {
+		return 0, err
+	}
+	deadline := time.Now().Add(-ttl)
+	ret := 0
+	for _, path := range paths {
+		t, err := s.read(path)
+		if err != nil {
+			return ret, err
+		}
+		if !t.UpdatedAt.Before(deadline) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return ret, err
+		}
+		ret++
+	}
+	return ret, nil
+}
+
+var _ Store = (*FileStore)(nil)
@@ -0,0 +1,272 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"golang.org/x/xerrors"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS conversation_transcripts (
+	id         TEXT PRIMARY KEY,
+	user_id    TEXT NOT NULL DEFAULT '',
+	metadata   TEXT NOT NULL DEFAULT '{}',
+	version    INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS conversation_transcripts_user_id ON conversation_transcripts (user_id, updated_at);
+CREATE INDEX IF NOT EXISTS conversation_transcripts_updated_at ON conversation_transcripts (updated_at);
+CREATE TABLE IF NOT EXISTS conversation_messages (
+	transcript_id TEXT NOT NULL,
+	seq           INTEGER NOT NULL,
+	message       TEXT NOT NULL,
+	PRIMARY KEY (transcript_id, seq)
+);
+`
+
+// stores transcripts in SQLite. The driver is not imported by this package,
+// open db with a driver such as modernc.org/sqlite or github.com/mattn/go-sqlite3.
+// Appends from several processes are serialized by the version check in a transaction,
+// set a busy timeout on the database so that concurrent writers wait for the lock.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// create the tables if they do not exist
+func NewSQLiteStore(ctx context.Context, db *sql.DB) (*SQLiteStore, error) {
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, xerrors.Errorf("failed to create the tables: %w", err)
+	}
+	return &SQLiteStore{
+		db: db,
+	}, nil
+}
+
+func (s *SQLiteStore) Create(ctx context.Context, t *Transcript) error {
+	t, err := newTranscript(t, time.Now())
+	if err != nil {
+		return err
+	}
+	metadata, err := json.Marshal(t.Metadata)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT 1 FROM conversation_transcripts WHERE id = ?`, t.ID).Scan(&exists)
+	if err == nil {
+		return xerrors.Errorf("id: %s, error: %w", t.ID, ErrTranscriptExists)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO conversation_transcripts (id, user_id, metadata, version, created_at, updated_at) VALUES (?, ?, ?, 0, ?, ?)`,
+		t.ID, t.UserID, string(metadata), t.CreatedAt.UnixNano(), t.UpdatedAt.UnixNano(),
+	); err != nil {
+		return err
+	}
+	if err := insertMessages(ctx, tx, t.ID, 0, t.Messages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertMessages(ctx context.Context, tx *sql.Tx, id string, seq int, messages []api.Message) error {
+	for i, m := range messages {
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO conversation_messages (transcript_id, seq, message) VALUES (?, ?, ?)`,
+			id, seq+i, string(b),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func scanTranscripts(ctx context.Context, q sqlQueryer, where string, args ...any) ([]*Transcript, error) {
+	rows, err := q.QueryContext(
+		ctx,
+		`SELECT id, user_id, metadata, version, created_at, updated_at FROM conversation_transcripts `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ret := []*Transcript{}
+	for rows.Next() {
+		t := &Transcript{}
+		var metadata string
+		var createdAt, updatedAt int64
+		if err := rows.Scan(&t.ID, &t.UserID, &metadata, &t.Version, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(metadata), &t.Metadata); err != nil {
+			return nil, xerrors.Errorf("id: %s, error: %w", t.ID, err)
+		}
+		t.CreatedAt = time.Unix(0, createdAt)
+		t.UpdatedAt = time.Unix(0, updatedAt)
+		ret = append(ret, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, t := range ret {
+		if t.Messages, err = loadMessages(ctx, q, t.ID); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func loadMessages(ctx context.Context, q sqlQueryer, id string) ([]api.Message, error) {
+	rows, err := q.QueryContext(ctx, `SELECT message FROM conversation_messages WHERE transcript_id = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ret := []api.Message{}
+	for rows.Next() {
+		var b string
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		m := api.Message{}
+		if err := json.Unmarshal([]byte(b), &m); err != nil {
+			return nil, xerrors.Errorf("id: %s, error: %w", id, err)
+		}
+		ret = append(ret, m)
+	}
+	return ret, rows.Err()
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id string) (*Transcript, error) {
+	ret, err := scanTranscripts(ctx, s.db, `WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, xerrors.Errorf("id: %s, error: %w", id, ErrTranscriptNotFound)
+	}
+	return ret[0], nil
+}
+
+func (s *SQLiteStore) Append(ctx context.Context, id string, version int, messages ...api.Message) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE conversation_transcripts SET version = version + 1, updated_at = ? WHERE id = ? AND version = ?`,
+		time.Now().UnixNano(), id, version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		var actual int
+		err := tx.QueryRowContext(ctx, `SELECT version FROM conversation_transcripts WHERE id = ?`, id).Scan(&actual)
+		if err == sql.ErrNoRows {
+			return 0, xerrors.Errorf("id: %s, error: %w", id, ErrTranscriptNotFound)
+		}
+		if err != nil {
+			return 0, err
+		}
+		return 0, xerrors.Errorf("id: %s, expected: %d, actual: %d, error: %w", id, version, actual, ErrVersionConflict)
+	}
+
+	var seq int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM conversation_messages WHERE transcript_id = ?`, id).Scan(&seq); err != nil {
+		return 0, err
+	}
+	if err := insertMessages(ctx, tx, id, seq, messages); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+func (s *SQLiteStore) ListByUser(ctx context.Context, userID string) ([]*Transcript, error) {
+	return scanTranscripts(ctx, s.db, `WHERE user_id = ? ORDER BY updated_at DESC`, userID)
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `DELETE FROM conversation_transcripts WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return xerrors.Errorf("id: %s, error: %w", id, ErrTranscriptNotFound)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM conversation_messages WHERE transcript_id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Prune(ctx context.Context, ttl time.Duration) (int, error) {
+	deadline := time.Now().Add(-ttl).UnixNano()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM conversation_messages WHERE transcript_id IN (SELECT id FROM conversation_transcripts WHERE updated_at < ?)`,
+		deadline,
+	); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM conversation_transcripts WHERE updated_at < ?`, deadline)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+var _ Store = (*SQLiteStore)(nil)
@@ -0,0 +1,122 @@
+package conversation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"golang.org/x/xerrors"
+)
+
+// behavior shared by every Store implementation
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	old := time.Now().Add(-48 * time.Hour)
+	for _, tr := range []*Transcript{
+		{ID: "a", UserID: "alice", Messages: []api.Message{{Role: "user", Content: "hello"}}},
+		{ID: "b", UserID: "alice", CreatedAt: old},
+		{ID: "c", UserID: "bob", Metadata: map[string]string{"topic": "go"}},
+	} {
+		if err := s.Create(ctx, tr); err != nil {
+			t.Fatalf("Create(%s) error: %v", tr.ID, err)
+		}
+	}
+
+	errTests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"create an existing id", s.Create(ctx, &Transcript{ID: "a"}), ErrTranscriptExists},
+		{"create an invalid id", s.Create(ctx, &Transcript{ID: "../a"}), ErrInvalidTranscript},
+		{"delete an unknown id", s.Delete(ctx, "unknown"), ErrTranscriptNotFound},
+	}
+	for _, tt := range errTests {
+		if !xerrors.Is(tt.err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+	if _, err := s.Get(ctx, "unknown"); !xerrors.Is(err, ErrTranscriptNotFound) {
+		t.Errorf("Get(unknown) error = %v, want ErrTranscriptNotFound", err)
+	}
+
+	version, err := s.Append(ctx, "a", 0, api.Message{Role: "assistant", Content: "hi"})
+	if err != nil || version != 1 {
+		t.Fatalf("Append() = %d, %v, want 1", version, err)
+	}
+	if _, err := s.Append(ctx, "a", 0, api.Message{Role: "user", Content: "stale"}); !xerrors.Is(err, ErrVersionConflict) {
+		t.Errorf("Append() with a stale version error = %v, want ErrVersionConflict", err)
+	}
+	got, err := s.Get(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []api.Message{{Role: "user", Content: "hello"}, {Role: "assistant", Content: "hi"}}
+	if got.Version != 1 || !reflect.DeepEqual(got.Messages, want) {
+		t.Errorf("Get(a) = version %d, messages %+v, want version 1, messages %+v", got.Version, got.Messages, want)
+	}
+	if got, err := s.Get(ctx, "c"); err != nil || got.Metadata["topic"] != "go" {
+		t.Errorf("Get(c) = %+v, %v, want the metadata", got, err)
+	}
+
+	list, err := s.ListByUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "a" || list[1].ID != "b" {
+		t.Errorf("ListByUser(alice) = %+v, want a then b", list)
+	}
+
+	if n, err := s.Prune(ctx, 24*time.Hour); err != nil || n != 1 {
+		t.Errorf("Prune() = %d, %v, want 1", n, err)
+	}
+	if err := s.Delete(ctx, "c"); err != nil {
+		t.Errorf("Delete(c) error: %v", err)
+	}
+	for _, id := range []string{"b", "c"} {
+		if _, err := s.Get(ctx, id); !xerrors.Is(err, ErrTranscriptNotFound) {
+			t.Errorf("Get(%s) error = %v, want ErrTranscriptNotFound", id, err)
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+}
+
+// a write torn by a crash is ignored and overwritten by the next append
+func TestFileStoreTornWrite(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(ctx, &Transcript{ID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.Dir, "a.jsonl"), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"version": 1, "messages": [{"role": "us`)
+	f.Close()
+
+	if _, err := s.Append(ctx, "a", 0, api.Message{Role: "user", Content: "hello"}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	got, err := s.Get(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.Message{{Role: "user", Content: "hello"}}; got.Version != 1 || !reflect.DeepEqual(got.Messages, want) {
+		t.Errorf("Get(a) = version %d, messages %+v, want version 1, messages %+v", got.Version, got.Messages, want)
+	}
+}
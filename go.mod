@@ -1,11 +1,13 @@
 module github.com/ieee0824/gopenai-api
 
-go 1.20
+go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/danielgtaylor/huma v1.14.1
 	github.com/samber/lo v1.38.1
 	golang.org/x/xerrors v0.0.0-20231012003039-104605ab7028
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
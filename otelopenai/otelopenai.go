@@ -0,0 +1,457 @@
+// Package otelopenai instruments the api client with OpenTelemetry.
+// It is a separate module so that the api package does not depend on OpenTelemetry.
+//
+// Each call is traced with a client span following the GenAI semantic conventions,
+// and the duration and the token usage are recorded as the gen_ai.client.operation.duration
+// and gen_ai.client.token.usage histograms.
+// The span of a stream ends when the stream ends, fails or is closed, so close the streams.
+// The token usage of a stream is recorded from its last chunk. stream_options.include_usage is set
+// on the streams without stream options, so the last chunk has the usage and no choices.
+package otelopenai
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ieee0824/gopenai-api/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/ieee0824/gopenai-api/otelopenai"
+
+// attributes of the GenAI semantic conventions
+const (
+	attrSystem                = attribute.Key("gen_ai.system")
+	attrOperationName         = attribute.Key("gen_ai.operation.name")
+	attrRequestModel          = attribute.Key("gen_ai.request.model")
+	attrRequestMaxTokens      = attribute.Key("gen_ai.request.max_tokens")
+	attrRequestTemperature    = attribute.Key("gen_ai.request.temperature")
+	attrRequestTopP           = attribute.Key("gen_ai.request.top_p")
+	attrRequestPresence       = attribute.Key("gen_ai.request.presence_penalty")
+	attrRequestFrequency      = attribute.Key("gen_ai.request.frequency_penalty")
+	attrRequestStopSequences  = attribute.Key("gen_ai.request.stop_sequences")
+	attrRequestSeed           = attribute.Key("gen_ai.request.seed")
+	attrRequestChoiceCount    = attribute.Key("gen_ai.request.choice.count")
+	attrResponseID            = attribute.Key("gen_ai.response.id")
+	attrResponseModel         = attribute.Key("gen_ai.response.model")
+	attrResponseFinishReasons = attribute.Key("gen_ai.response.finish_reasons")
+	attrUsageInputTokens      = attribute.Key("gen_ai.usage.input_tokens")
+	attrUsageOutputTokens     = attribute.Key("gen_ai.usage.output_tokens")
+	attrTokenType             = attribute.Key("gen_ai.token.type")
+	attrServiceTier           = attribute.Key("gen_ai.openai.response.service_tier")
+	attrSystemFingerprint     = attribute.Key("gen_ai.openai.response.system_fingerprint")
+	attrRequestID             = attribute.Key("openai.request.id")
+	attrServerAddress         = attribute.Key("server.address")
+	attrServerPort            = attribute.Key("server.port")
+	attrErrorType             = attribute.Key("error.type")
+	attrHTTPStatusCode        = attribute.Key("http.response.status_code")
+)
+
+// bucket boundaries advised by the semantic conventions
+var (
+	durationBoundaries = []float64{0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92}
+	tokenBoundaries    = []float64{1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864}
+)
+
+// gen_ai.operation.name of the endpoints, the others are traced with the endpoint name
+var operationNames = map[string]string{
+	api.EndpointChatCompletionsV1:       "chat",
+	api.EndpointChatCompletionsV1Stream: "chat",
+	api.EndpointCompletionsV1:           "text_completion",
+	api.EndpointCompletionsV1Stream:     "text_completion",
+	api.EndpointImagesGenerationsV1:     "generate_content",
+	api.EndpointAudioTranscriptionsV1:   "generate_content",
+}
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+	system         string
+}
+
+type Option func(*config)
+
+// default: otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// default: otel.GetMeterProvider()
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// inject the span context into the http requests. default: otel.GetTextMapPropagator()
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagators = p
+	}
+}
+
+// the gen_ai.system attribute, such as az.ai.openai for Azure OpenAI. default: openai
+func WithSystem(system string) Option {
+	return func(c *config) {
+		c.system = system
+	}
+}
+
+type instrumentation struct {
+	config
+	tracer     trace.Tracer
+	duration   metric.Float64Histogram
+	tokenUsage metric.Int64Histogram
+}
+
+// create an interceptor which traces and measures the calls.
+// The span is a child of the span in the context passed to the WithContext and Stream methods.
+// example:
+//
+//	interceptor, err := otelopenai.NewInterceptor()
+//	if err != nil {
+//	    return err
+//	}
+//	ai := api.New(cfg, api.WithInterceptor(interceptor))
+func NewInterceptor(opts ...Option) (*api.Interceptor, error) {
+	i := &instrumentation{
+		config: config{
+			tracerProvider: otel.GetTracerProvider(),
+			meterProvider:  otel.GetMeterProvider(),
+			propagators:    otel.GetTextMapPropagator(),
+			system:         "openai",
+		},
+	}
+	for _, opt := range opts {
+		opt(&i.config)
+	}
+	i.tracer = i.tracerProvider.Tracer(instrumentationName)
+	meter := i.meterProvider.Meter(instrumentationName)
+
+	var err error
+	i.duration, err = meter.Float64Histogram(
+		"gen_ai.client.operation.duration",
+		metric.WithDescription("GenAI operation duration"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBoundaries...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	i.tokenUsage, err = meter.Int64Histogram(
+		"gen_ai.client.token.usage",
+		metric.WithDescription("Measures number of input and output tokens used"),
+		metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(tokenBoundaries...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Interceptor{
+		Call:      i.call,
+		RoundTrip: i.roundTrip,
+	}, nil
+}
+
+func (i *instrumentation) call(ctx context.Context, call *api.Call, next api.CallHandler) (any, error) {
+	start := time.Now()
+	model := call.Model()
+	operation, ok := operationNames[call.Endpoint]
+	name := call.Endpoint
+	if ok {
+		name = operation
+		if model != "" {
+			name += " " + model
+		}
+	}
+
+	common := []attribute.KeyValue{
+		attrSystem.String(i.system),
+	}
+	if operation != "" {
+		common = append(common, attrOperationName.String(operation))
+	}
+	if model != "" {
+		common = append(common, attrRequestModel.String(model))
+	}
+	ctx, span := i.tracer.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(common...),
+		trace.WithAttributes(requestAttributes(call.Input)...),
+	)
+	includeUsage(call)
+
+	output, err := next(ctx, call)
+
+	if call.Request != nil {
+		common = append(common, serverAttributes(call.Request)...)
+	}
+	md := api.OutputResponseMetadata(output)
+	if md == nil {
+		md, _ = api.ResponseMetadataFromError(err)
+	}
+	if md != nil {
+		span.SetAttributes(attrHTTPStatusCode.Int(md.StatusCode))
+		if md.RequestID != "" {
+			span.SetAttributes(attrRequestID.String(md.RequestID))
+		}
+	}
+
+	// the span of a stream ends when the stream ends, fails or is closed
+	switch stream := output.(type) {
+	case *api.Stream[api.ChatCompletionsV1StreamChunk]:
+		if stream != nil && err == nil {
+			r := &response{}
+			stream.Observe(r.addChatChunk, func(err error) {
+				i.end(ctx, span, start, common, md, r, err)
+			})
+			return output, err
+		}
+	case *api.Stream[api.CompletionsV1Output]:
+		if stream != nil && err == nil {
+			r := &response{}
+			stream.Observe(r.addCompletionsChunk, func(err error) {
+				i.end(ctx, span, start, common, md, r, err)
+			})
+			return output, err
+		}
+	}
+	i.end(ctx, span, start, common, md, newResponse(output), err)
+	return output, err
+}
+
+// end the span of a call and record the duration and the token usage
+func (i *instrumentation) end(ctx context.Context, span trace.Span, start time.Time, common []attribute.KeyValue, md *api.ResponseMetadata, r *response, err error) {
+	defer span.End()
+
+	if r.usage != nil {
+		span.SetAttributes(
+			attrUsageInputTokens.Int(r.usage.PromptTokens),
+			attrUsageOutputTokens.Int(r.usage.CompletionTokens),
+		)
+	}
+	span.SetAttributes(r.attributes()...)
+	model := ""
+	if r.model != nil {
+		model = *r.model
+	} else if md != nil {
+		model = md.Model
+	}
+	if model != "" {
+		common = append(common, attrResponseModel.String(model))
+	}
+	if err != nil {
+		errorType := "_OTHER"
+		if md != nil && md.StatusCode >= http.StatusBadRequest {
+			errorType = strconv.Itoa(md.StatusCode)
+		}
+		common = append(common, attrErrorType.String(errorType))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(common...)
+
+	i.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(common...))
+	if r.usage != nil {
+		i.tokenUsage.Record(ctx, int64(r.usage.PromptTokens), metric.WithAttributes(append(common, attrTokenType.String("input"))...))
+		i.tokenUsage.Record(ctx, int64(r.usage.CompletionTokens), metric.WithAttributes(append(common, attrTokenType.String("output"))...))
+	}
+}
+
+// request the usage in the last chunk of the streams which do not set the stream options
+func includeUsage(call *api.Call) {
+	include := true
+	options := &api.ChatCompletionsV1InputStreamOptions{IncludeUsage: &include}
+	switch input := call.Input.(type) {
+	case *api.ChatCompletionsV1Input:
+		if call.Endpoint == api.EndpointChatCompletionsV1Stream && input != nil && input.StreamOptions == nil {
+			in := *input
+			in.StreamOptions = options
+			call.Input = &in
+		}
+	case *api.CompletionsV1Input:
+		if call.Endpoint == api.EndpointCompletionsV1Stream && input != nil && input.StreamOptions == nil {
+			in := *input
+			in.StreamOptions = options
+			call.Input = &in
+		}
+	}
+}
+
+func (i *instrumentation) roundTrip(call *api.Call, req *http.Request, next api.RoundTripHandler) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	i.propagators.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return next(req)
+}
+
+func requestAttributes(input any) []attribute.KeyValue {
+	ret := []attribute.KeyValue{}
+	switch input := input.(type) {
+	case *api.ChatCompletionsV1Input:
+		if input.MaxCompletionTokens != nil {
+			ret = append(ret, attrRequestMaxTokens.Int(*input.MaxCompletionTokens))
+		} else if input.MaxTokens != nil {
+			ret = append(ret, attrRequestMaxTokens.Int(*input.MaxTokens))
+		}
+		ret = appendFloat(ret, attrRequestTemperature, input.Temperature)
+		ret = appendFloat(ret, attrRequestTopP, input.TopP)
+		ret = appendFloat(ret, attrRequestPresence, input.PresencePenalty)
+		ret = appendFloat(ret, attrRequestFrequency, input.FrequencyPenalty)
+		if len(input.Stop) > 0 {
+			ret = append(ret, attrRequestStopSequences.StringSlice(input.Stop))
+		}
+		if input.Seed != nil {
+			ret = append(ret, attrRequestSeed.Int(*input.Seed))
+		}
+		if input.N > 1 {
+			ret = append(ret, attrRequestChoiceCount.Int(input.N))
+		}
+	case *api.CompletionsV1Input:
+		if input.MaxTokens != nil {
+			ret = append(ret, attrRequestMaxTokens.Int(*input.MaxTokens))
+		}
+		ret = appendFloat(ret, attrRequestTemperature, input.Temperature)
+		ret = appendFloat(ret, attrRequestTopP, input.TopP)
+		ret = appendFloat(ret, attrRequestPresence, input.PresencePenalty)
+		ret = appendFloat(ret, attrRequestFrequency, input.FrequencyPenalty)
+		if len(input.Stop) > 0 {
+			ret = append(ret, attrRequestStopSequences.StringSlice(input.Stop))
+		}
+		if input.Seed != nil {
+			ret = append(ret, attrRequestSeed.Int(*input.Seed))
+		}
+		if input.N != nil && *input.N > 1 {
+			ret = append(ret, attrRequestChoiceCount.Int(*input.N))
+		}
+	}
+	return ret
+}
+
+func appendFloat(attrs []attribute.KeyValue, key attribute.Key, v *float32) []attribute.KeyValue {
+	if v == nil {
+		return attrs
+	}
+	return append(attrs, key.Float64(float64(*v)))
+}
+
+// the response attributes of an output, or of the chunks of a stream
+type response struct {
+	id                *string
+	model             *string
+	serviceTier       *string
+	systemFingerprint *string
+	finishReasons     []string // nil for the outputs without choices
+	usage             *api.ChatCompletionsV1OutputUsage
+}
+
+func newResponse(output any) *response {
+	r := &response{}
+	switch output := output.(type) {
+	case *api.ChatCompletionsV1Output:
+		if output == nil {
+			break
+		}
+		r.id, r.model, r.serviceTier, r.systemFingerprint = output.ID, output.Model, output.ServiceTier, output.SystemFingerprint
+		r.finishReasons = []string{}
+		for _, c := range output.Choices {
+			r.finishReasons = append(r.finishReasons, c.FinishReason)
+		}
+	case *api.CompletionsV1Output:
+		if output == nil {
+			break
+		}
+		r.id, r.model, r.systemFingerprint = output.ID, output.Model, output.SystemFingerprint
+		r.finishReasons = []string{}
+		for _, c := range output.Choices {
+			r.finishReasons = append(r.finishReasons, c.FinishReason)
+		}
+	}
+	r.usage = api.OutputUsage(output)
+	return r
+}
+
+func (r *response) addChatChunk(chunk *api.ChatCompletionsV1StreamChunk) {
+	r.id, r.model = or(chunk.ID, r.id), or(chunk.Model, r.model)
+	r.serviceTier, r.systemFingerprint = or(chunk.ServiceTier, r.serviceTier), or(chunk.SystemFingerprint, r.systemFingerprint)
+	if r.finishReasons == nil {
+		r.finishReasons = []string{}
+	}
+	for _, c := range chunk.Choices {
+		if c.FinishReason != nil {
+			r.finishReasons = append(r.finishReasons, *c.FinishReason)
+		}
+	}
+	if chunk.Usage != nil {
+		r.usage = chunk.Usage
+	}
+}
+
+func (r *response) addCompletionsChunk(chunk *api.CompletionsV1Output) {
+	r.id, r.model = or(chunk.ID, r.id), or(chunk.Model, r.model)
+	r.systemFingerprint = or(chunk.SystemFingerprint, r.systemFingerprint)
+	if r.finishReasons == nil {
+		r.finishReasons = []string{}
+	}
+	for _, c := range chunk.Choices {
+		if c.FinishReason != "" {
+			r.finishReasons = append(r.finishReasons, c.FinishReason)
+		}
+	}
+	if chunk.Usage != nil {
+		r.usage = chunk.Usage
+	}
+}
+
+func (r *response) attributes() []attribute.KeyValue {
+	ret := []attribute.KeyValue{}
+	if r.id != nil {
+		ret = append(ret, attrResponseID.String(*r.id))
+	}
+	if r.finishReasons != nil {
+		ret = append(ret, attrResponseFinishReasons.StringSlice(r.finishReasons))
+	}
+	if r.serviceTier != nil {
+		ret = append(ret, attrServiceTier.String(*r.serviceTier))
+	}
+	if r.systemFingerprint != nil {
+		ret = append(ret, attrSystemFingerprint.String(*r.systemFingerprint))
+	}
+	return ret
+}
+
+// v if it is set, otherwise the previous value
+func or(v, prev *string) *string {
+	if v != nil {
+		return v
+	}
+	return prev
+}
+
+func serverAttributes(req *http.Request) []attribute.KeyValue {
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if port == "" {
+		port = "443"
+		if req.URL.Scheme == "http" {
+			port = "80"
+		}
+	}
+	ret := []attribute.KeyValue{
+		attrServerAddress.String(host),
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		ret = append(ret, attrServerPort.Int(p))
+	}
+	return ret
+}
@@ -0,0 +1,117 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielgtaylor/huma/schema"
+	"github.com/ieee0824/gopenai-api/api"
+	"golang.org/x/xerrors"
+)
+
+// overheads of the chat format.
+// doc: https://cookbook.openai.com/examples/how_to_count_tokens_with_tiktoken
+const (
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	tokensPerReply   = 3 // every reply is primed with <|start|>assistant<|message|>
+
+	toolPropertiesInit = 3
+	toolPropertyKey    = 3
+	toolEnumInit       = -3
+	toolEnumItem       = 3
+	toolsEnd           = 12
+)
+
+func (e *Encoding) toolInit() int {
+	if e.name == Cl100kBase {
+		return 10
+	}
+	return 7
+}
+
+// count the prompt tokens of messages and tool definitions, tools can be nil.
+// The result is an estimate, the format of the chat is not published.
+func (e *Encoding) CountChatTokens(messages []api.Message, tools []*api.Tool) int {
+	ret := tokensPerReply
+	for _, m := range messages {
+		ret += tokensPerMessage
+		ret += e.Count(m.Role) + e.Count(m.Content) + e.Count(m.ToolCallID) + e.Count(m.Refusal)
+		if m.Name != "" {
+			ret += e.Count(m.Name) + tokensPerName
+		}
+		for _, tc := range m.ToolCalls {
+			if tc.Function == nil {
+				continue
+			}
+			ret += e.Count(tc.Function.Name) + e.Count(tc.Function.Arguments)
+		}
+	}
+
+	if len(tools) == 0 {
+		return ret
+	}
+	for _, tool := range tools {
+		if tool.Function != nil {
+			ret += e.countFunction(tool.Function)
+		}
+	}
+	return ret + toolsEnd
+}
+
+func (e *Encoding) countFunction(f *api.Function) int {
+	ret := e.toolInit() + e.Count(f.Name+":"+strings.TrimSuffix(f.Description, "."))
+	if f.Paramaters == nil || len(f.Paramaters.Properties) == 0 {
+		return ret
+	}
+	ret += toolPropertiesInit
+	for name, p := range f.Paramaters.Properties {
+		ret += toolPropertyKey + e.countProperty(name, p)
+	}
+	return ret
+}
+
+func (e *Encoding) countProperty(name string, p *schema.Schema) int {
+	ret := 0
+	if len(p.Enum) > 0 {
+		ret += toolEnumInit
+		for _, item := range p.Enum {
+			ret += toolEnumItem + e.Count(fmt.Sprint(item))
+		}
+	}
+	return ret + e.Count(name+":"+p.Type+":"+strings.TrimSuffix(p.Description, "."))
+}
+
+// count the prompt tokens of an input with the encoding of its model.
+// The deprecated Functions are counted as tools.
+func CountChatCompletionsV1InputTokens(input *api.ChatCompletionsV1Input) (int, error) {
+	if input.Model == nil {
+		return 0, xerrors.New("model is empty")
+	}
+	e, err := EncodingForModel(*input.Model)
+	if err != nil {
+		return 0, err
+	}
+	return e.countInput(input), nil
+}
+
+func (e *Encoding) countInput(input *api.ChatCompletionsV1Input) int {
+	tools := input.Tools
+	for _, f := range input.Functions {
+		tools = append(tools[:len(tools):len(tools)], &api.Tool{Type: "function", Function: f})
+	}
+	return e.CountChatTokens(input.Messages, tools)
+}
+
+// for api.Limiter.EstimateChatTokens. Unknown models are counted with o200k_base.
+func EstimateChatTokens(input *api.ChatCompletionsV1Input) int {
+	ret, err := CountChatCompletionsV1InputTokens(input)
+	if err == nil {
+		return ret
+	}
+	e, err := GetEncoding(O200kBase)
+	if err != nil {
+		return 0
+	}
+	return e.countInput(input)
+}
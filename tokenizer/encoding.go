@@ -0,0 +1,289 @@
+// Package tokenizer implements the byte pair encodings of tiktoken offline.
+// The rank files of cl100k_base and o200k_base are embedded from https://github.com/openai/tiktoken (MIT License).
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	Cl100kBase = "cl100k_base"
+	O200kBase  = "o200k_base"
+)
+
+var ErrUnknownEncoding = xerrors.New("unknown encoding")
+
+//go:embed assets/cl100k_base.tiktoken
+var cl100kBaseRanks []byte
+
+//go:embed assets/o200k_base.tiktoken
+var o200kBaseRanks []byte
+
+// Go regexp has no lookahead, the \s+(?!\S) alternative of tiktoken is emulated in split.
+// \s is replaced with the unicode white space because it is ascii only in Go.
+const (
+	cl100kBasePattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|(\s+)`
+	o200kBasePattern  = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|(\s+)`
+)
+
+func compilePattern(pattern string) *regexp.Regexp {
+	const space = `\t\n\v\f\r \x{85}\p{Z}`
+	pattern = strings.ReplaceAll(pattern, `[^\s`, `[^`+space)
+	pattern = strings.ReplaceAll(pattern, `\s`, `[`+space+`]`)
+	return regexp.MustCompile(`^(?:` + pattern + `)`)
+}
+
+type encodingSpec struct {
+	ranks   []byte
+	pattern string
+	special map[string]int
+}
+
+var specs = map[string]encodingSpec{
+	Cl100kBase: {
+		ranks:   cl100kBaseRanks,
+		pattern: cl100kBasePattern,
+		special: map[string]int{
+			"<|endoftext|>":   100257,
+			"<|fim_prefix|>":  100258,
+			"<|fim_middle|>":  100259,
+			"<|fim_suffix|>":  100260,
+			"<|endofprompt|>": 100276,
+		},
+	},
+	O200kBase: {
+		ranks:   o200kBaseRanks,
+		pattern: o200kBasePattern,
+		special: map[string]int{
+			"<|endoftext|>":   199999,
+			"<|endofprompt|>": 200018,
+		},
+	},
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = map[string]*Encoding{}
+)
+
+// a byte pair encoding. It is safe for concurrent use.
+type Encoding struct {
+	name          string
+	ranks         map[string]int
+	decoder       map[int][]byte
+	special       map[string]int
+	specialTokens []string // longest first
+	pattern       *regexp.Regexp
+}
+
+// get an encoding by name, the rank file is parsed on the first call
+func GetEncoding(name string) (*Encoding, error) {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	if e, ok := encodings[name]; ok {
+		return e, nil
+	}
+	spec, ok := specs[name]
+	if !ok {
+		return nil, xerrors.Errorf("encoding: %s, error: %w", name, ErrUnknownEncoding)
+	}
+	e, err := newEncoding(name, spec)
+	if err != nil {
+		return nil, err
+	}
+	encodings[name] = e
+	return e, nil
+}
+
+func newEncoding(name string, spec encodingSpec) (*Encoding, error) {
+	ret := &Encoding{
+		name:    name,
+		ranks:   map[string]int{},
+		decoder: map[int][]byte{},
+		special: spec.special,
+		pattern: compilePattern(spec.pattern),
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(spec.ranks))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		i := bytes.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, xerrors.Errorf("invalid rank file of %s: %s", name, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(line[:i]))
+		if err != nil {
+			return nil, xerrors.Errorf("invalid rank file of %s: %w", name, err)
+		}
+		rank, err := strconv.Atoi(string(line[i+1:]))
+		if err != nil {
+			return nil, xerrors.Errorf("invalid rank file of %s: %w", name, err)
+		}
+		ret.ranks[string(token)] = rank
+		ret.decoder[rank] = token
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("invalid rank file of %s: %w", name, err)
+	}
+	for token, rank := range spec.special {
+		ret.decoder[rank] = []byte(token)
+		ret.specialTokens = append(ret.specialTokens, token)
+	}
+	sort.Slice(ret.specialTokens, func(i, j int) bool {
+		return len(ret.specialTokens[i]) > len(ret.specialTokens[j])
+	})
+	return ret, nil
+}
+
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// encode text, special tokens such as <|endoftext|> are encoded as ordinary text
+func (e *Encoding) Encode(text string) []int {
+	ret := []int{}
+	for _, piece := range e.split(text) {
+		ret = e.encodePiece(ret, piece)
+	}
+	return ret
+}
+
+// encode text, special tokens such as <|endoftext|> are encoded as the special tokens
+func (e *Encoding) EncodeWithSpecialTokens(text string) []int {
+	ret := []int{}
+	for len(text) > 0 {
+		next, token := e.nextSpecialToken(text)
+		for _, piece := range e.split(text[:next]) {
+			ret = e.encodePiece(ret, piece)
+		}
+		if token == "" {
+			break
+		}
+		ret = append(ret, e.special[token])
+		text = text[next+len(token):]
+	}
+	return ret
+}
+
+func (e *Encoding) nextSpecialToken(text string) (int, string) {
+	next, ret := len(text), ""
+	for _, token := range e.specialTokens {
+		if i := strings.Index(text, token); i >= 0 && i < next {
+			next, ret = i, token
+		}
+	}
+	return next, ret
+}
+
+// number of tokens of text
+func (e *Encoding) Count(text string) int {
+	return len(e.Encode(text))
+}
+
+// decode tokens into bytes, unknown tokens are skipped
+func (e *Encoding) DecodeBytes(tokens []int) []byte {
+	ret := []byte{}
+	for _, t := range tokens {
+		ret = append(ret, e.decoder[t]...)
+	}
+	return ret
+}
+
+// decode tokens into a string, invalid utf-8 sequences are kept as they are
+func (e *Encoding) Decode(tokens []int) string {
+	return string(e.DecodeBytes(tokens))
+}
+
+// split text into the pieces which are encoded independently
+func (e *Encoding) split(text string) []string {
+	ret := []string{}
+	for len(text) > 0 {
+		m := e.pattern.FindStringSubmatchIndex(text)
+		if m == nil || m[1] == 0 {
+			// unreachable, every character matches one of the alternatives
+			ret = append(ret, text)
+			break
+		}
+		end := m[1]
+		// \s+(?!\S): a white space run followed by a non white space leaves its last character
+		// to the next piece, such as " " of " hello"
+		if m[2] >= 0 && end < len(text) {
+			last := strings.LastIndexFunc(text[:end], func(rune) bool { return true })
+			if last > 0 {
+				end = last
+			}
+		}
+		ret = append(ret, text[:end])
+		text = text[end:]
+	}
+	return ret
+}
+
+func (e *Encoding) encodePiece(dst []int, piece string) []int {
+	if rank, ok := e.ranks[piece]; ok {
+		return append(dst, rank)
+	}
+	for _, part := range e.bytePairMerge(piece) {
+		dst = append(dst, e.ranks[part])
+	}
+	return dst
+}
+
+// merge the adjacent pair with the lowest rank until no pair can be merged
+func (e *Encoding) bytePairMerge(piece string) []string {
+	// boundaries of the parts
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	rank := func(i int) int {
+		if i+2 >= len(bounds) {
+			return math.MaxInt
+		}
+		if r, ok := e.ranks[piece[bounds[i]:bounds[i+2]]]; ok {
+			return r
+		}
+		return math.MaxInt
+	}
+	ranks := make([]int, len(bounds))
+	for i := range ranks {
+		ranks[i] = rank(i)
+	}
+	for len(bounds) > 2 {
+		lowest, at := math.MaxInt, -1
+		for i := 0; i < len(bounds)-2; i++ {
+			if ranks[i] < lowest {
+				lowest, at = ranks[i], i
+			}
+		}
+		if at < 0 {
+			break
+		}
+		bounds = append(bounds[:at+1], bounds[at+2:]...)
+		ranks = append(ranks[:at+1], ranks[at+2:]...)
+		ranks[at] = rank(at)
+		if at > 0 {
+			ranks[at-1] = rank(at - 1)
+		}
+	}
+	ret := make([]string, 0, len(bounds)-1)
+	for i := 0; i < len(bounds)-1; i++ {
+		ret = append(ret, piece[bounds[i]:bounds[i+1]])
+	}
+	return ret
+}
@@ -0,0 +1,89 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// token ids produced by tiktoken
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		want     []int
+	}{
+		{Cl100kBase, "hello world", []int{15339, 1917}},
+		{Cl100kBase, "Hello, world!", []int{9906, 11, 1917, 0}},
+		{Cl100kBase, "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{Cl100kBase, "<|endoftext|>", []int{27, 91, 8862, 728, 428, 91, 29}},
+		{O200kBase, "hello world", []int{24912, 2375}},
+		{O200kBase, "Hello, world!", []int{13225, 11, 2375, 0}},
+		{O200kBase, "tiktoken is great!", []int{83, 8251, 2488, 382, 2212, 0}},
+		{O200kBase, "<|endoftext|>", []int{27, 91, 419, 1440, 919, 91, 29}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding+"/"+tt.text, func(t *testing.T) {
+			e, err := GetEncoding(tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := e.Encode(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if s := e.Decode(got); s != tt.text {
+				t.Errorf("Decode(%v) = %q, want %q", got, s, tt.text)
+			}
+		})
+	}
+}
+
+func TestEncodeWithSpecialTokens(t *testing.T) {
+	tests := []struct {
+		encoding string
+		want     []int
+	}{
+		{Cl100kBase, []int{15339, 100257}},
+		{O200kBase, []int{24912, 199999}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			e, err := GetEncoding(tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := e.EncodeWithSpecialTokens("hello<|endoftext|>"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EncodeWithSpecialTokens = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodingNameForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-4", Cl100kBase},
+		{"gpt-4-0613", Cl100kBase},
+		{"gpt-3.5-turbo-0125", Cl100kBase},
+		{"gpt-4o", O200kBase},
+		{"gpt-4o-mini-2024-07-18", O200kBase},
+		{"ft:gpt-4o-mini-2024-07-18:org::id", O200kBase},
+		{"o3-mini", O200kBase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, err := EncodingNameForModel(tt.model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodingNameForModel(%q) = %q, want %q", tt.model, got, tt.want)
+			}
+		})
+	}
+	if _, err := EncodingNameForModel("unknown-model"); err == nil {
+		t.Error("EncodingNameForModel(unknown-model) error = nil")
+	}
+}
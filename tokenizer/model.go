@@ -0,0 +1,73 @@
+package tokenizer
+
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+var ErrUnknownModel = xerrors.New("unknown model")
+
+// model name to encoding
+var modelEncodings = map[string]string{
+	"gpt-4":                  Cl100kBase,
+	"gpt-3.5-turbo":          Cl100kBase,
+	"gpt-35-turbo":           Cl100kBase, // Azure
+	"text-embedding-ada-002": Cl100kBase,
+	"text-embedding-3-small": Cl100kBase,
+	"text-embedding-3-large": Cl100kBase,
+	"davinci-002":            Cl100kBase,
+	"babbage-002":            Cl100kBase,
+	"gpt-4o":                 O200kBase,
+	"gpt-4.1":                O200kBase,
+	"gpt-4.5":                O200kBase,
+	"gpt-5":                  O200kBase,
+	"o1":                     O200kBase,
+	"o3":                     O200kBase,
+	"o4-mini":                O200kBase,
+}
+
+// model name prefix to encoding, the longest prefix wins
+var modelPrefixEncodings = map[string]string{
+	"gpt-4-":         Cl100kBase,
+	"gpt-3.5-turbo-": Cl100kBase,
+	"gpt-35-turbo-":  Cl100kBase,
+	"gpt-4o-":        O200kBase,
+	"gpt-4.1-":       O200kBase,
+	"gpt-4.5-":       O200kBase,
+	"gpt-5-":         O200kBase,
+	"chatgpt-4o-":    O200kBase,
+	"o1-":            O200kBase,
+	"o3-":            O200kBase,
+	"o4-":            O200kBase,
+}
+
+// name of the encoding of a model.
+// Fine-tuned models such as ft:gpt-4o-mini-2024-07-18:org::id use the encoding of the base model.
+func EncodingNameForModel(model string) (string, error) {
+	model = strings.TrimPrefix(model, "ft:")
+	if i := strings.Index(model, ":"); i >= 0 {
+		model = model[:i]
+	}
+	if name, ok := modelEncodings[model]; ok {
+		return name, nil
+	}
+	ret, longest := "", 0
+	for prefix, name := range modelPrefixEncodings {
+		if strings.HasPrefix(model, prefix) && len(prefix) > longest {
+			ret, longest = name, len(prefix)
+		}
+	}
+	if ret == "" {
+		return "", xerrors.Errorf("model: %s, error: %w", model, ErrUnknownModel)
+	}
+	return ret, nil
+}
+
+func EncodingForModel(model string) (*Encoding, error) {
+	name, err := EncodingNameForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return GetEncoding(name)
+}